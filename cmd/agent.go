@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/agent"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/components/agentview"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/executor"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	agentMaxSteps  int
+	agentAutoApply bool
+)
+
+// agentCmd - multi-turn agent mode with tool calling
+var agentCmd = &cobra.Command{
+	Use:   "agent [task]",
+	Short: "Let the model work on a task using the file tools",
+	Long: `Run the model in agent mode. It can list directories, read, create and delete files and run commands in the workspace
+until it gives a final answer or reaches the step limit. Mutating tools need your approval unless --yes is passed.`,
+	Example: `  agent-code agent "add a README section describing the cmd package"`,
+	RunE:    runAgent,
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+
+	agentCmd.Flags().IntVar(&agentMaxSteps, "max-steps", agent.DefaultMaxSteps, "maximum model round trips before giving up")
+	agentCmd.Flags().BoolVarP(&agentAutoApply, "yes", "y", false, "approve mutating tool calls without asking")
+	agentCmd.Flags().StringVarP(&modelName, "model", "m", "", "model to use instead of the configured one")
+}
+
+func runAgent(cmd *cobra.Command, args []string) error {
+	task, err := readQuestion(args)
+	if err != nil {
+		return err
+	}
+
+	llm, err := newProvider()
+	if err != nil {
+		return validationError(err)
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return err
+	}
+
+	// tool paths are relative to the workspace root
+	if err := os.Chdir(ws.Root); err != nil {
+		return fmt.Errorf("error entering workspace root: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	a := &agent.Agent{
+		Provider:     llm,
+		Tools:        getToolRegistry(),
+		SystemPrompt: agentSystemPrompt(ws.Root),
+		MaxSteps:     agentMaxSteps,
+	}
+
+	if !canRender() || !canPrompt() {
+		return runAgentPlain(ctx, a, task)
+	}
+
+	view := agentview.InitialAgentViewModel(task, cancel)
+	tProgram := tea.NewProgram(view)
+
+	a.OnEvent = func(event agent.Event) {
+		tProgram.Send(agentview.EventMsg{Event: event})
+	}
+	a.Approve = func(ctx context.Context, call provider.ToolCall) (bool, error) {
+		if agentAutoApply || preApproved(call) {
+			return true, nil
+		}
+
+		reply := make(chan bool, 1)
+		tProgram.Send(agentview.ApprovalMsg{Call: call, Preview: approvalPreview(ctx, call), Reply: reply})
+
+		select {
+		case approved := <-reply:
+			return approved, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+
+	go func() {
+		_, err := a.Run(ctx, task)
+		tProgram.Send(agentview.DoneMsg{Err: err})
+	}()
+
+	if _, err := tProgram.Run(); err != nil {
+		return err
+	}
+
+	if view.Cancelled() {
+		return cancelledError("Agent operation cancelled.")
+	}
+
+	return view.Err()
+}
+
+// runAgentPlain - agent loop printing plain text, mutating tools only run with --yes
+func runAgentPlain(ctx context.Context, a *agent.Agent, task string) error {
+	a.OnEvent = func(event agent.Event) {
+		switch e := event.(type) {
+		case agent.TextEvent:
+			fmt.Print(e.Text)
+		case agent.ToolCallEvent:
+			fmt.Printf("\n> %s %s\n", e.Call.Name, e.Call.Arguments)
+		case agent.ToolResultEvent:
+			switch {
+			case e.Denied:
+				fmt.Println("  denied, pass --yes to allow mutating tools")
+			case e.Err != nil:
+				fmt.Printf("  error: %v\n", e.Err)
+			default:
+				fmt.Printf("  %d bytes returned\n", len(e.Result))
+			}
+		}
+	}
+	a.Approve = func(ctx context.Context, call provider.ToolCall) (bool, error) {
+		return agentAutoApply || preApproved(call), nil
+	}
+
+	if _, err := a.Run(ctx, task); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// approvalPreview - the diff an edit call would make, empty for other tools or when it fails
+func approvalPreview(ctx context.Context, call provider.ToolCall) string {
+	if call.Name != editFileTool {
+		return ""
+	}
+
+	var args editArgs
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return ""
+	}
+	args.DryRun = true
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	result, err := editFileFromTool(ctx, data)
+	if err != nil {
+		return ""
+	}
+
+	_, patch, _ := strings.Cut(result, "\n")
+	return ui.RenderDiff(patch)
+}
+
+// preApproved - commands on the run allow list need no confirmation, denied ones
+// are let through to the executor which rejects them with a reason for the model
+func preApproved(call provider.ToolCall) bool {
+	if call.Name != runCommandTool {
+		return false
+	}
+
+	var args runCommandArgs
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return false
+	}
+
+	argv, err := commandArgs([]string{args.Command})
+	if err != nil {
+		return false
+	}
+
+	ex, err := newExecutor()
+	if err != nil {
+		return false
+	}
+
+	return ex.Decide(argv) != executor.NeedsApproval
+}
+
+func agentSystemPrompt(root string) string {
+	return fmt.Sprintf("You are agent-code, a coding assistant working inside the project at %s. "+
+		"Use the tools to inspect and change files, all paths are relative to the project root. "+
+		"Mutating tools may be denied by the user, do not retry a denied call. "+
+		"When the task is done reply with a short summary of what you did.", root)
+}
@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/diff"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	applyCheck   bool
+	applyReverse bool
+	applyStrip   int
+	applyFuzz    int
+)
+
+// applyArgs - arguments of the apply tool, strip and fuzz default to -1 and diff.DefaultFuzz
+type applyArgs struct {
+	Patch   string `json:"patch"`
+	Check   bool   `json:"check,omitempty"`
+	Reverse bool   `json:"reverse,omitempty"`
+	Strip   *int   `json:"strip,omitempty"`
+	Fuzz    *int   `json:"fuzz,omitempty"`
+}
+
+// applyCmd - apply a unified diff to the workspace
+var applyCmd = &cobra.Command{
+	Use:   "apply [patch]",
+	Short: "Apply a unified diff or git patch to the workspace",
+	Long: `Apply a unified diff, as written by diff -u, git diff or git format-patch, read from the patch file or stdin.
+Files are created, deleted and renamed as the patch says, renames only come from git's rename headers; the two
+names of a plain diff are one file, whichever of them exists. Hunks whose lines moved are found above or below where their
+header puts them, and with --fuzz up to that many context lines at either end of a hunk may differ. Nothing is written
+unless every file of the patch applies; --check only reports whether it would. Every change is recorded so
+'agent-code undo' can roll it back.`,
+	Example: `  agent-code apply fix.patch
+  git diff main | agent-code apply --check
+  agent-code apply --reverse fix.patch`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: applyPatchFile,
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().BoolVar(&applyCheck, "check", false, "only check that the patch applies, nothing is written")
+	applyCmd.Flags().BoolVarP(&applyReverse, "reverse", "R", false, "undo the patch instead of applying it")
+	applyCmd.Flags().IntVarP(&applyStrip, "strip", "p", -1, "leading directories to remove from file names, by default the a/ and b/ of git diffs")
+	applyCmd.Flags().IntVarP(&applyFuzz, "fuzz", "F", diff.DefaultFuzz, "context lines at either end of a hunk that may differ")
+}
+
+func applyPatchFile(cmd *cobra.Command, args []string) error {
+	patch, err := readPatch(args)
+	if err != nil {
+		return err
+	}
+
+	toolArgs := applyArgs{Patch: patch, Check: applyCheck, Reverse: applyReverse, Strip: &applyStrip, Fuzz: &applyFuzz}
+
+	// every file is checked before the tool writes anything
+	if _, err := planPatch(toolArgs); err != nil {
+		return validationError(err)
+	}
+
+	result, err := getToolRegistry().Call(cmd.Context(), applyPatchTool, toolArgs)
+	if err != nil {
+		return err
+	}
+
+	summary, report, _ := strings.Cut(result, "\n")
+	if report != "" {
+		fmt.Println(report)
+	}
+	fmt.Println(ui.RenderSuccess(summary))
+	return nil
+}
+
+// readPatch - the patch file of args, or stdin when there is none or it is -
+func readPatch(args []string) (string, error) {
+	if len(args) == 1 && args[0] != "-" {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return "", validationError(fmt.Errorf("error reading patch: %w", err))
+		}
+		return string(data), nil
+	}
+
+	if canPrompt() {
+		return "", validationError(fmt.Errorf("pass the patch file as an argument or on stdin"))
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("error reading patch: %w", err)
+	}
+	return string(data), nil
+}
+
+// patchTarget - a workspace file as the patch leaves it, content is nil when it does not exist.
+// executable is set for a file the patch creates with git's 100755 mode
+type patchTarget struct {
+	name       string
+	path       string
+	existed    bool
+	before     string
+	content    *string
+	executable bool
+}
+
+// patchPlan - the files a patch changes in the order it first touches them, and what it did to each
+type patchPlan struct {
+	targets []*patchTarget
+	report  []string
+	files   int
+	added   int
+	removed int
+}
+
+// planPatch - the result of applying the patch to the workspace, nothing is written
+func planPatch(args applyArgs) (*patchPlan, error) {
+	strip, fuzz := -1, diff.DefaultFuzz
+	if args.Strip != nil {
+		strip = *args.Strip
+	}
+	if args.Fuzz != nil {
+		fuzz = *args.Fuzz
+	}
+
+	files, err := diff.Parse(args.Patch)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &patchPlan{}
+	byPath := map[string]*patchTarget{}
+	target := func(name string) (*patchTarget, error) {
+		path, err := resolveWritable(name)
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := byPath[path]; ok {
+			return t, nil
+		}
+
+		t := &patchTarget{name: name, path: path}
+		if _, err := os.Lstat(path); err == nil {
+			_, before, err := readEditable(name)
+			if err != nil {
+				return nil, err
+			}
+			t.existed, t.before, t.content = true, before, &before
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error accessing %s: %w", name, err)
+		}
+
+		byPath[path] = t
+		plan.targets = append(plan.targets, t)
+		return t, nil
+	}
+
+	// exists - the file is there, as the patch left it so far
+	exists := func(name string) bool {
+		path, err := resolvePath(name)
+		if err != nil {
+			return false
+		}
+		if t, ok := byPath[path]; ok {
+			return t.content != nil
+		}
+		_, err = os.Lstat(path)
+		return err == nil
+	}
+
+	for _, file := range files {
+		if args.Reverse {
+			file = file.Reverse()
+		}
+
+		oldName, newName := file.Paths(strip)
+		name := newName
+		switch {
+		case file.Deleted():
+			name = oldName
+		case !file.Created() && !file.Renamed:
+			// only git's rename headers rename, a plain diff names one file twice (diff -u foo.txt.orig foo.txt)
+			name = patchName(oldName, newName, exists)
+			oldName, newName = name, name
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%w: hunks without file names, the --- and +++ lines are missing", diff.ErrInvalidPatch)
+		}
+		if err := checkModes(name, file); err != nil {
+			return nil, err
+		}
+		plan.files++
+
+		var source, dest *patchTarget
+		if !file.Created() {
+			if source, err = target(oldName); err != nil {
+				return nil, err
+			}
+			if source.content == nil {
+				return nil, fmt.Errorf("%s does not exist", oldName)
+			}
+		}
+		if !file.Deleted() {
+			if dest, err = target(newName); err != nil {
+				return nil, err
+			}
+			if dest != source && dest.content != nil {
+				return nil, fmt.Errorf("%s already exists", newName)
+			}
+		}
+
+		before := ""
+		if source != nil {
+			before = *source.content
+		}
+		lines, placements, err := diff.Apply(diff.SplitLines(before), file.Hunks, fuzz)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		after := strings.Join(lines, "")
+
+		added, removed := 0, 0
+		for _, hunk := range file.Hunks {
+			a, r := hunk.Stats()
+			added += a
+			removed += r
+		}
+		plan.added += added
+		plan.removed += removed
+		stats := fmt.Sprintf("(+%d -%d)", added, removed)
+
+		switch {
+		case file.Deleted():
+			if after != "" {
+				return nil, fmt.Errorf("%s has lines the patch does not remove, it is not deleted", oldName)
+			}
+			source.content = nil
+			plan.report = append(plan.report, fmt.Sprintf("delete %s %s", oldName, stats))
+		case file.Created():
+			dest.content, dest.executable = &after, file.NewMode == executableMode
+			plan.report = append(plan.report, fmt.Sprintf("create %s %s", newName, stats))
+		case source != dest:
+			source.content, dest.content = nil, &after
+			plan.report = append(plan.report, fmt.Sprintf("rename %s to %s %s", oldName, newName, stats))
+		default:
+			dest.content = &after
+			plan.report = append(plan.report, fmt.Sprintf("patch %s %s", newName, stats))
+		}
+
+		for i, placement := range placements {
+			if placement.Offset == 0 && placement.Fuzz == 0 {
+				continue
+			}
+			note := fmt.Sprintf("offset %d", placement.Offset)
+			if placement.Fuzz > 0 {
+				note += fmt.Sprintf(", fuzz %d", placement.Fuzz)
+			}
+			plan.report = append(plan.report, fmt.Sprintf("  hunk %d applied at line %d (%s)", i+1, file.Hunks[i].OldStart+placement.Offset, note))
+		}
+	}
+
+	return plan, nil
+}
+
+// git file modes a patch can create files with
+const (
+	regularMode    = "100644"
+	executableMode = "100755"
+)
+
+// patchName - the file a diff without git's rename headers changes, the way patch(1) picks it:
+// of the names that exist the one with the fewest directories, then the shortest base name, then
+// the shortest name. the new name, or the old one when there is no new one, when neither exists
+func patchName(oldName, newName string, exists func(name string) bool) string {
+	best := ""
+	for _, name := range []string{oldName, newName} {
+		if name == "" || !exists(name) {
+			continue
+		}
+		if best == "" || betterPatchName(name, best) {
+			best = name
+		}
+	}
+
+	switch {
+	case best != "":
+		return best
+	case newName != "":
+		return newName
+	}
+	return oldName
+}
+
+func betterPatchName(name, than string) bool {
+	if a, b := strings.Count(name, "/"), strings.Count(than, "/"); a != b {
+		return a < b
+	}
+	if a, b := len(path.Base(name)), len(path.Base(than)); a != b {
+		return a < b
+	}
+	return len(name) < len(than)
+}
+
+// checkModes - the patch only sets the mode of a file it creates, a regular or executable one.
+// mode changes of existing files, symlinks and submodules are rejected rather than left out
+func checkModes(name string, file *diff.File) error {
+	switch {
+	case file.Created():
+		if file.NewMode != "" && file.NewMode != regularMode && file.NewMode != executableMode {
+			return fmt.Errorf("%s: creating files with mode %s is not supported", name, file.NewMode)
+		}
+	case file.Deleted():
+	case file.OldMode != file.NewMode:
+		return fmt.Errorf("%s: mode changes (%s to %s) are not supported", name, file.OldMode, file.NewMode)
+	}
+	return nil
+}
+
+// write - make the changes of the plan, every file is recorded in the journal on its own
+func (p *patchPlan) write(ctx context.Context) (int, error) {
+	j, err := getJournal()
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for _, t := range p.targets {
+		switch {
+		case !t.existed && t.content != nil:
+			fsPlan, err := filesystem.Create(t.path, []byte(*t.content), false)
+			if err != nil {
+				return written, err
+			}
+			if _, err := j.RecordCreate(t.path, []byte(*t.content), fsPlan.Dirs); err != nil {
+				return written, fmt.Errorf("file %s created but not recorded in the journal: %w", t.name, err)
+			}
+			if t.executable {
+				if err := os.Chmod(t.path, 0755); err != nil {
+					return written, fmt.Errorf("error making %s executable: %w", t.name, err)
+				}
+			}
+		case t.existed && t.content == nil:
+			if _, err := getToolRegistry().Call(ctx, deletePathTool, pathArgs{Path: t.path}); err != nil {
+				return written, err
+			}
+		case t.existed && *t.content != t.before:
+			if _, err := writeEdit(t.path, t.before, *t.content); err != nil {
+				return written, err
+			}
+		default:
+			continue
+		}
+		written++
+	}
+
+	return written, nil
+}
+
+// applyPatchFromTool - apply a patch and return a summary line followed by what was done to every file
+func applyPatchFromTool(ctx context.Context, data json.RawMessage) (string, error) {
+	var args applyArgs
+	if err := json.Unmarshal(data, &args); err != nil {
+		return "", fmt.Errorf("invalid tool arguments: %w", err)
+	}
+
+	plan, err := planPatch(args)
+	if err != nil {
+		return "", err
+	}
+
+	report := strings.Join(plan.report, "\n")
+	if args.Check {
+		return fmt.Sprintf("patch applies cleanly to %d %s (+%d -%d)\n%s", plan.files, plural(plan.files, "file", "files"), plan.added, plan.removed, report), nil
+	}
+
+	written, err := plan.write(ctx)
+	if err != nil {
+		if written > 0 {
+			return "", fmt.Errorf("%w. the %d %s changed before are listed by `agent-code history`", err, written, plural(written, "file", "files"))
+		}
+		return "", err
+	}
+
+	return fmt.Sprintf("applied patch to %d %s (+%d -%d)\n%s", plan.files, plural(plan.files, "file", "files"), plan.added, plan.removed, report), nil
+}
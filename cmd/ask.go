@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/components/streamview"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/components/textinput"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/walker"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxContextFileSize - files bigger than this are not attached to the prompt
+const maxContextFileSize = 256 * 1024
+
+var (
+	askFiles []string
+	askPick  bool
+)
+
+// askCmd - one-shot question about the workspace
+var askCmd = &cobra.Command{
+	Use:   "ask [question]",
+	Short: "Ask the model a question about the workspace",
+	Long: `Ask the configured model a one-shot question, optionally attaching workspace files as context with --file,
+or by picking them in a fuzzy finder with --pick.
+The question is read from the arguments, or from stdin when no arguments are given.`,
+	Example: `  agent-code ask --file 'cmd/*.go' "how are exit codes handled?"
+  agent-code ask --pick "what does this do?"
+  git diff | agent-code ask`,
+	RunE: ask,
+}
+
+func init() {
+	rootCmd.AddCommand(askCmd)
+
+	askCmd.Flags().StringSliceVarP(&askFiles, "file", "f", nil, "file, directory or glob pattern to attach as context (repeatable)")
+	askCmd.Flags().BoolVar(&askPick, "pick", false, "pick files and directories to attach in a fuzzy finder, tab marks more than one")
+	askCmd.Flags().StringVarP(&modelName, "model", "m", "", "model to use instead of the configured one")
+}
+
+func ask(cmd *cobra.Command, args []string) error {
+	question, err := readQuestion(args)
+	if err != nil {
+		return err
+	}
+
+	// picked files are attached along with the --file ones
+	if askPick {
+		if !canPrompt() {
+			return validationError(fmt.Errorf("--pick needs a terminal, pass the files with --file instead"))
+		}
+
+		paths, quit, err := pickFiles("Find files to attach ...", true, true, nil)
+		if err != nil {
+			return err
+		}
+		if quit {
+			return cancelledError("Ask operation cancelled.")
+		}
+		askFiles = append(askFiles, paths...)
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	files, err := expandContextFiles(ctx, askFiles)
+	if err != nil {
+		return validationError(err)
+	}
+
+	prompt, err := buildContextPrompt(question, files)
+	if err != nil {
+		return err
+	}
+
+	llm, err := newProvider()
+	if err != nil {
+		return validationError(err)
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return err
+	}
+
+	req := provider.Request{
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: askSystemPrompt(ws.Root)},
+			{Role: provider.RoleUser, Content: prompt},
+		},
+	}
+
+	// plain text when piped
+	if !canRender() {
+		stream, err := llm.Stream(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if _, err := provider.Collect(stream, func(chunk provider.Chunk) {
+			fmt.Print(chunk.Content)
+		}); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		return nil
+	}
+
+	view := streamview.InitialStreamViewModel(truncate(question, 72), cancel)
+	tProgram := tea.NewProgram(view)
+
+	go func() {
+		stream, err := llm.Stream(ctx, req)
+		if err != nil {
+			tProgram.Send(streamview.DoneMsg{Err: err})
+			return
+		}
+
+		resp, err := provider.Collect(stream, func(chunk provider.Chunk) {
+			if chunk.Content != "" {
+				tProgram.Send(streamview.ChunkMsg(chunk.Content))
+			}
+		})
+		if err != nil {
+			tProgram.Send(streamview.DoneMsg{Err: err})
+			return
+		}
+
+		tProgram.Send(streamview.DoneMsg{Usage: resp.Usage})
+	}()
+
+	if _, err := tProgram.Run(); err != nil {
+		return err
+	}
+
+	if view.Cancelled() {
+		return cancelledError("Ask operation cancelled.")
+	}
+
+	return view.Err()
+}
+
+// readQuestion - question from args, piped stdin or an interactive prompt
+func readQuestion(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+
+	if !canPrompt() {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("error reading question from stdin: %w", err)
+		}
+
+		question := strings.TrimSpace(string(data))
+		if question == "" {
+			return "", validationError(fmt.Errorf("question cannot be empty"))
+		}
+		return question, nil
+	}
+
+	output := &textinput.Output{}
+	tProgram := tea.NewProgram(textinput.InitialTextInputModel(
+		output,
+		"What do you want to ask?",
+		func(input string) (bool, error) {
+			if strings.TrimSpace(input) == "" {
+				return false, fmt.Errorf("question cannot be empty")
+			}
+			return true, nil
+		},
+	))
+
+	if _, err := tProgram.Run(); err != nil {
+		return "", err
+	}
+
+	if output.Quit {
+		return "", cancelledError("Ask operation cancelled.")
+	}
+
+	return output.Output, nil
+}
+
+// expandContextFiles - expand file globs into workspace paths. directories attach the text files
+// below them that ignore files do not leave out and that are small enough
+func expandContextFiles(ctx context.Context, patterns []string) ([]string, error) {
+	ws, err := getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+		}
+
+		// a path that exists as written is taken literally, even with glob characters in its name
+		if _, err := os.Lstat(pattern); err == nil {
+			matches = []string{pattern}
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+
+		for _, match := range matches {
+			path, err := resolvePath(match)
+			if err != nil {
+				return nil, err
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("error accessing %s: %w", match, err)
+			}
+			if !info.IsDir() {
+				// a named file that cannot be attached is an error, unlike the ones found in a directory
+				content, err := filesystem.Sniff(path)
+				if err != nil {
+					return nil, fmt.Errorf("error reading %s: %w", match, err)
+				}
+				if content.Binary() {
+					return nil, fmt.Errorf("%w: %s (%s) cannot be attached", filesystem.ErrBinary, match, content.MIME)
+				}
+				if !seen[path] {
+					seen[path] = true
+					files = append(files, path)
+				}
+				continue
+			}
+
+			tree, err := walker.Walk(ctx, path, walker.Options{Ignore: true, IgnoreRoot: ws.Root})
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", match, err)
+			}
+			for _, node := range tree.Flatten() {
+				if node.IsDir || seen[node.Path] || node.Size > maxContextFileSize || !node.Mode.IsRegular() {
+					continue
+				}
+				if content, err := filesystem.Sniff(node.Path); err != nil || content.Binary() {
+					continue
+				}
+
+				seen[node.Path] = true
+				files = append(files, node.Path)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// buildContextPrompt - question followed by the attached files in fenced blocks
+func buildContextPrompt(question string, files []string) (string, error) {
+	if len(files) == 0 {
+		return question, nil
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return "", err
+	}
+
+	var s strings.Builder
+	s.WriteString(question + "\n\n")
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		if info.Size() > maxContextFileSize {
+			return "", validationError(fmt.Errorf("file %s is too large to attach (%d bytes, max %d)", ws.Rel(file), info.Size(), maxContextFileSize))
+		}
+
+		lines, err := readFileLines(file)
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %w", ws.Rel(file), err)
+		}
+
+		s.WriteString(fmt.Sprintf("File: %s\n```%s\n%s\n```\n\n", ws.Rel(file), strings.TrimPrefix(filepath.Ext(file), "."), strings.Join(lines, "\n")))
+	}
+
+	return s.String(), nil
+}
+
+func askSystemPrompt(root string) string {
+	return fmt.Sprintf("You are agent-code, a coding assistant running in a terminal. "+
+		"Answer questions about the project at %s concisely, using the attached files when given. "+
+		"Reply in plain text suitable for a terminal.", root)
+}
+
+// truncate - shorten text to max runes for headers
+func truncate(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
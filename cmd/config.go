@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/config"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	cfgFile       string
+	currentConfig *config.Config
+	revealSecrets bool
+	setProject    bool
+)
+
+// configCmd - inspect and edit the settings
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and edit the configuration",
+	Long: fmt.Sprintf(`Settings are layered, later ones win: built in defaults, the user config file, the project's .agent-code.yaml,
+environment variables and finally command line flags. A checked out project is not trusted with %s,
+those are only read from the user config and the environment.`, strings.Join(config.UserOnlyKeys, ", ")),
+	// the config commands must keep working when a config file is broken
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+// configShowCmd - print the effective settings
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration as YAML",
+	Args:  cobra.NoArgs,
+	RunE:  showConfig,
+}
+
+// configGetCmd - print a single setting
+var configGetCmd = &cobra.Command{
+	Use:     "get key",
+	Short:   "Print the effective value of a setting",
+	Example: `  agent-code config get model.name`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    getConfigValue,
+}
+
+// configSetCmd - write a setting to a config file
+var configSetCmd = &cobra.Command{
+	Use:   "set key value",
+	Short: "Write a setting to the user config file, or the project file with --project",
+	Long: fmt.Sprintf(`Write a setting to the user config file, or the project's .agent-code.yaml with --project.
+Lists are comma separated. Keys: %s`, strings.Join(config.Keys(), ", ")),
+	Example: `  agent-code config set model.name qwen2.5-coder
+  agent-code config set --project create.extensions .go,.ts`,
+	Args: cobra.ExactArgs(2),
+	RunE: setConfigValue,
+}
+
+// configPathCmd - print the config file locations
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file locations",
+	Args:  cobra.NoArgs,
+	RunE:  printConfigPaths,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd, configGetCmd, configSetCmd, configPathCmd)
+
+	configShowCmd.Flags().BoolVar(&revealSecrets, "reveal", false, "print the API key instead of masking it")
+	configSetCmd.Flags().BoolVar(&setProject, "project", false, "write to the project's .agent-code.yaml instead of the user config")
+}
+
+// initConfig - load the settings and apply the ones used by every command, run before each command
+func initConfig(cmd *cobra.Command, args []string) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return validationError(err)
+	}
+
+	if err := ui.ApplyTheme(cfg.UI.Theme); err != nil {
+		return validationError(err)
+	}
+
+	return nil
+}
+
+// getConfig - layered settings, loaded once per run
+func getConfig() (*config.Config, error) {
+	if currentConfig != nil {
+		return currentConfig, nil
+	}
+
+	cfg, err := config.Load(config.Options{File: cfgFile, Root: workspaceRoot})
+	if err != nil {
+		return nil, err
+	}
+
+	currentConfig = cfg
+	return currentConfig, nil
+}
+
+func showConfig(cmd *cobra.Command, args []string) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	out, err := cfg.YAML(revealSecrets)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range cfg.Files {
+		fmt.Printf("# loaded %s\n", file)
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
+func getConfigValue(cmd *cobra.Command, args []string) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return validationError(err)
+	}
+
+	fmt.Println(config.FormatValue(value))
+	return nil
+}
+
+func setConfigValue(cmd *cobra.Command, args []string) error {
+	if setProject && config.UserOnly(args[0]) {
+		return validationError(fmt.Errorf("%s %w", args[0], config.ErrUserOnlyKey))
+	}
+
+	path, err := configFilePath(setProject)
+	if err != nil {
+		return err
+	}
+
+	if err := config.SetFile(path, args[0], args[1]); err != nil {
+		return validationError(err)
+	}
+
+	fmt.Println(ui.RenderSuccess(fmt.Sprintf("%s set in %s", args[0], path)))
+	return nil
+}
+
+func printConfigPaths(cmd *cobra.Command, args []string) error {
+	for _, project := range []bool{false, true} {
+		path, err := configFilePath(project)
+		if err != nil {
+			return err
+		}
+
+		status := "missing"
+		if _, err := os.Stat(path); err == nil {
+			status = "found"
+		}
+
+		name := "user"
+		if project {
+			name = "project"
+		}
+		fmt.Printf("%-8s %s (%s)\n", name, path, status)
+	}
+
+	return nil
+}
+
+// configFilePath - user config file, --config when given, or the project file of the workspace
+func configFilePath(project bool) (string, error) {
+	if !project {
+		if cfgFile != "" {
+			return cfgFile, nil
+		}
+		return config.UserPath()
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		// a broken config must still be fixable, detect the root without it
+		if ws, err = workspace.Detect(workspaceRoot); err != nil {
+			return "", err
+		}
+	}
+
+	return config.ProjectPath(ws.Root), nil
+}
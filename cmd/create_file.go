@@ -1,17 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/components/textinput"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/templates"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
 	"github.com/spf13/cobra"
-	"os"
 	"path/filepath"
 	"strings"
 )
 
-var fileName string
+var (
+	fileName       string
+	createTemplate string
+	createDryRun   bool
+)
 
 type CreateOptions struct {
 	FileName *textinput.Output
@@ -19,18 +26,40 @@ type CreateOptions struct {
 
 // createFileCmd - create a new file
 var createFileCmd = &cobra.Command{
-	Use:   "create",
+	Use:   "create [file]",
 	Short: "Create a new file for a given programming language",
-	Long:  `Creating a new file for a given programming language. You can create a file of any of the following languages: go, js, py, php`,
-	Run:   createFile,
+	Long: `Creating a new file for a given programming language. By default you can create a file of any of the following languages: go, js, py, php,
+the list is set with create.extensions in the config.
+The file body comes from a template, see 'agent-code templates list' for the ones available per extension.
+Pass the file path as an argument to skip the interactive prompt.`,
+	Example: `  agent-code create src/main.go
+  agent-code create --template=http-handler api/users.go
+  agent-code create --dry-run internal/api/users.go`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: createFile,
 }
 
 func init() {
 	rootCmd.AddCommand(createFileCmd)
+
+	createFileCmd.Flags().StringVarP(&createTemplate, "template", "T", templates.DefaultName, "template to generate the file from")
+	createFileCmd.Flags().BoolVar(&createDryRun, "dry-run", false, "print the directories and file that would be written without writing them")
 }
 
-func createFile(cmd *cobra.Command, args []string) {
-	allowedExtensions := []string{".go", ".js", ".py", ".php"}
+func createFile(cmd *cobra.Command, args []string) error {
+	// non-interactive, file name given as argument
+	if len(args) == 1 {
+		return runCreate(cmd.Context(), args[0])
+	}
+
+	if !canPrompt() {
+		return validationError(fmt.Errorf("file name argument is required when stdin is not a terminal"))
+	}
+
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
 
 	options := CreateOptions{
 		FileName: &textinput.Output{},
@@ -39,28 +68,110 @@ func createFile(cmd *cobra.Command, args []string) {
 	// handle program create, passing values
 	tProgram := tea.NewProgram(textinput.InitialTextInputModel(
 		options.FileName,
-		fmt.Sprintf("Create a new file. Allowed languages are %s", strings.Join(allowedExtensions, ",")),
+		fmt.Sprintf("Create a new file. Allowed languages are %s", strings.Join(cfg.Create.Extensions, ",")),
 		func(input string) (bool, error) {
-			return validateFileCreate(input, allowedExtensions)
+			return validateFileCreate(input, cfg.Create.Extensions)
 		},
 	))
 
 	// run bubbletea program
 	if _, err := tProgram.Run(); err != nil {
-		cobra.CheckErr(err)
-		return
+		return err
 	}
 
 	if options.FileName.Quit {
-		fmt.Println("\n ❌Create file operation cancelled.")
+		return cancelledError("Create file operation cancelled.")
 	}
 
 	fileName = options.FileName.Output
 
-	if fileName != "" {
-		success := ui.RenderSuccess(fmt.Sprintf("file '%s' created successfully!", fileName))
-		fmt.Printf(success)
+	return runCreate(cmd.Context(), fileName)
+}
+
+// runCreate - create the file through the tool, with --dry-run print what would be written instead
+func runCreate(ctx context.Context, fileName string) error {
+	result, err := getToolRegistry().Call(ctx, createFileTool, pathArgs{Path: fileName, Template: createTemplate, DryRun: createDryRun})
+	if err != nil {
+		return validationError(err)
+	}
+
+	if createDryRun {
+		fmt.Println(result)
+		return nil
+	}
+
+	// the created directories come before the success message
+	lines := strings.Split(result, "\n")
+	printGeneratingFile(fileName)
+	for _, line := range lines[:len(lines)-1] {
+		fmt.Println(line)
+	}
+	fmt.Println(ui.RenderSuccess(lines[len(lines)-1]))
+	return nil
+}
+
+// createNewFile - validate the name, render the template and write the file,
+// with dryRun the plan is returned and nothing is written
+func createNewFile(fileName, templateName string, dryRun bool) (filesystem.Plan, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return filesystem.Plan{}, err
+	}
+
+	if _, err := validateFileCreate(fileName, cfg.Create.Extensions); err != nil {
+		return filesystem.Plan{}, err
+	}
+
+	path, err := resolveWritable(fileName)
+	if err != nil {
+		return filesystem.Plan{}, err
+	}
+
+	// a template that fails to render stops the create before anything is written
+	body, err := generateFileTemplate(path, templateName)
+	if err != nil {
+		return filesystem.Plan{}, err
+	}
+
+	plan, err := filesystem.Create(path, []byte(body), dryRun)
+	if err != nil || dryRun {
+		return plan, err
 	}
+
+	j, err := getJournal()
+	if err != nil {
+		return plan, err
+	}
+	if _, err := j.RecordCreate(path, []byte(body), plan.Dirs); err != nil {
+		return plan, fmt.Errorf("file %s created but not recorded in the journal: %w", fileName, err)
+	}
+
+	return plan, nil
+}
+
+// describeCreate - the directories and file a create wrote, or would write in a dry run
+func describeCreate(fileName string, plan filesystem.Plan, dryRun bool) (string, error) {
+	ws, err := getWorkspace()
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, dir := range plan.Dirs {
+		if dryRun {
+			lines = append(lines, fmt.Sprintf("would create directory %s/", ws.Rel(dir)))
+		} else {
+			lines = append(lines, fmt.Sprintf("created directory %s/", ws.Rel(dir)))
+		}
+	}
+
+	if dryRun {
+		lines = append(lines, fmt.Sprintf("would create file %s (%s)", ws.Rel(plan.Path), filesystem.FormatSize(int64(plan.Size))))
+	} else {
+		lines = append(lines, fmt.Sprintf("file '%s' created successfully!", fileName))
+	}
+
+	return strings.Join(lines, "\n"), nil
 }
 
 // check allowed file extensions
@@ -73,59 +184,36 @@ func isValidExtension(ext string, allowedExts []string) bool {
 	return false
 }
 
-// generate file template
-func generateFileTemplate(fileName string) string {
-	ext := filepath.Ext(fileName)
-
-	switch ext {
-	case ".go":
-		fmt.Printf("Generating file %s ... \n", ui.GoFileStyle.Render(fmt.Sprintf("%s", fileName)))
-	case ".js":
-
-		fmt.Printf("Generating file %s ... \n", ui.JSFileStyle.Render(fmt.Sprintf("%s", fileName)))
-	case ".py":
-
-		fmt.Printf("Generating file %s ... \n", ui.PythonFileStyle.Render(fmt.Sprintf("%s", fileName)))
-	case ".php":
+// print the file being generated in its language colour
+func printGeneratingFile(fileName string) {
+	fmt.Printf("Generating file %s ... \n", ui.GetFileStyle(filepath.Ext(fileName)).Render(fileName))
+}
 
-		fmt.Printf("Generating file %s ... \n", ui.PHPFileStyle.Render(fmt.Sprintf("%s", fileName)))
+// generate file template, an empty body when the extension has no default template
+func generateFileTemplate(fileName, templateName string) (string, error) {
+	set, err := getTemplates()
+	if err != nil {
+		return "", err
 	}
 
-	switch ext {
-	case ".go":
-		return `package main
-
-import "fmt"
-
-func main(){
-	fmt.Println("Hello world")
-}
-`
-	case ".js":
-		return `console.log("Hello world");`
-	case ".py":
-		return `print ("Hello world")`
-	case ".php":
-		return `<?php
-echo "Hello world"; 
-?> 
-`
-	default:
-		return ""
+	// _test.go files get the test skeleton unless a template is picked
+	isDefault := templateName == "" || templateName == templates.DefaultName
+	if isDefault {
+		templateName = templates.DefaultFor(fileName)
 	}
-}
 
-// create directory
-func generateFileDirectory(fileName string) error {
-	dir := filepath.Dir(fileName)
+	tmpl, err := set.Find(templateName, filepath.Ext(fileName))
+	if errors.Is(err, templates.ErrNotFound) && isDefault {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
 
-	if dir != "." && dir != "" {
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return err
-			}
-		}
+	ws, err := getWorkspace()
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return tmpl.Render(templates.NewData(ws.Root, ws.Rel(fileName)))
 }
@@ -3,73 +3,122 @@ package cmd
 import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/auth"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/components/passwordinput"
-	"github.com/nathanmbicho/agent-code-assignment/pkg/components/textinput"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
 	"github.com/spf13/cobra"
 	"os"
-	"path/filepath"
 )
 
-type options struct {
-	FileName *textinput.Output
-}
+var (
+	confirmDelete   bool
+	permanentDelete bool
+)
 
 // deleteFileCmd - delete an existing file or directory
 var deleteFileCmd = &cobra.Command{
-	Use:   "delete",
+	Use:   "delete [path]",
 	Short: "Delete an existing file or folder",
-	Long:  `You can delete an existing file or directory of given valid path.`,
-	Run:   deleteFile,
+	Long: `You can delete an existing file or directory of given valid path.
+Deleted items are moved to the trash and can be restored with 'agent-code trash restore', pass --permanent to delete for good.
+Pass the path as an argument together with --yes to delete without the interactive confirmation.
+The confirmation asks for your system password, --yes skips that check too so deletes can be scripted.`,
+	Example: `  agent-code delete --yes build/output.js
+  agent-code delete --permanent build`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: deleteFile,
 }
 
 func init() {
 	rootCmd.AddCommand(deleteFileCmd)
+
+	deleteFileCmd.Flags().BoolVarP(&confirmDelete, "yes", "y", false, "delete without the interactive confirmation and password check")
+	deleteFileCmd.Flags().BoolVar(&permanentDelete, "permanent", false, "delete permanently instead of moving to the trash")
 }
 
-func deleteFile(cmd *cobra.Command, args []string) {
-	//input command
-	option := options{
-		FileName: &textinput.Output{},
+func deleteFile(cmd *cobra.Command, args []string) error {
+	var targetPath string
+
+	if len(args) == 1 {
+		targetPath = args[0]
+	} else {
+		if !canPrompt() {
+			return validationError(fmt.Errorf("path argument is required when stdin is not a terminal"))
+		}
+
+		// pick from the workspace files and directories instead of typing the path
+		paths, quit, err := pickFiles("Find a file or directory to delete ...", false, true, func(path string) error {
+			_, _, err := validateDeleteFile(path)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if quit {
+			return cancelledError("Delete operation cancelled.")
+		}
+
+		targetPath = paths[0]
 	}
 
-	// handle program create, passing values
-	tProgram := tea.NewProgram(textinput.InitialTextInputModel(
-		option.FileName,
-		"Enter directory or file name to delete ...",
-		func(input string) (bool, error) {
-			return validateSearchFile(input)
-		},
-	))
-
-	// run bubbletea program
-	if _, err := tProgram.Run(); err != nil {
-		cobra.CheckErr(err)
-		return
+	absPath, isDir, err := validateDeleteFile(targetPath)
+	if err != nil {
+		return validationError(err)
 	}
 
-	if option.FileName.Quit {
-		fmt.Println("\n ❌Open file operation cancelled.")
+	// confirmed up front, no prompt and no password needed
+	if confirmDelete {
+		message, err := getToolRegistry().Call(cmd.Context(), deletePathTool, pathArgs{Path: targetPath, Permanent: permanentDelete})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(ui.RenderSuccess(message))
+		return nil
 	}
 
-	fmt.Println(ui.ErrorStyle.Render(fmt.Sprintf("You are about to delete %s", option.FileName.Output)))
+	if !canPrompt() {
+		return validationError(fmt.Errorf("refusing to delete %s without confirmation. pass --yes when stdin is not a terminal", targetPath))
+	}
 
-	absPath, isDir, err := validateDeleteFile(option.FileName.Output)
+	verifier, err := newPasswordVerifier()
 	if err != nil {
-		cobra.CheckErr(err)
-		return
+		return err
 	}
 
+	fmt.Println(ui.ErrorStyle.Render(fmt.Sprintf("You are about to delete %s", targetPath)))
+
 	// Start Bubble Tea program
-	tProgram = tea.NewProgram(passwordinput.InitialPasswordInputModel(absPath, isDir),
+	tProgram := tea.NewProgram(passwordinput.InitialPasswordInputModel(absPath, isDir, permanentDelete, verifier, func(path string) (string, error) {
+		return getToolRegistry().Call(cmd.Context(), deletePathTool, pathArgs{Path: path, Permanent: permanentDelete})
+	}),
 		tea.WithAltScreen(),
 	)
 
-	if _, err := tProgram.Run(); err != nil {
-		cobra.CheckErr(err)
-		return
+	model, err := tProgram.Run()
+	if err != nil {
+		return err
+	}
+
+	result := model.(passwordinput.Model)
+	if err := result.Err(); err != nil {
+		return err
+	}
+	if result.Cancelled() {
+		return cancelledError("Delete operation cancelled.")
 	}
 
+	return nil
+}
+
+// newPasswordVerifier - system password verifier, failed attempts are tracked across runs
+func newPasswordVerifier() (auth.Verifier, error) {
+	path, err := auth.DefaultStorePath()
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewRateLimited(auth.System(), &auth.FileStore{Path: path}), nil
 }
 
 func validateDeleteFile(targetPath string) (string, bool, error) {
@@ -77,14 +126,24 @@ func validateDeleteFile(targetPath string) (string, bool, error) {
 		return "", false, fmt.Errorf("path flag is required")
 	}
 
-	// Resolve the absolute path
-	absPath, err := filepath.Abs(targetPath)
+	ws, err := getWorkspace()
 	if err != nil {
-		return "", false, fmt.Errorf("error resolving path: %w", err)
+		return "", false, err
+	}
+
+	// Resolve the absolute path inside the workspace, a symlink is deleted itself not its target
+	absPath, err := ws.ResolveEntry(targetPath)
+	if err != nil {
+		return "", false, err
+	}
+
+	// never allow deleting the workspace root itself
+	if absPath == ws.Root {
+		return "", false, fmt.Errorf("refusing to delete the workspace root %s", ws.Root)
 	}
 
 	// Check if the path exists
-	info, err := os.Stat(absPath)
+	info, err := os.Lstat(absPath)
 	if os.IsNotExist(err) {
 		return "", false, fmt.Errorf("path does not exist: %s", absPath)
 	}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolateDelete - a fresh workspace at root with config, trash and auth state kept out of the home directory,
+// stdin is not a terminal so nothing can be typed
+func isolateDelete(t *testing.T, root string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	t.Setenv(workspace.RootEnv, root)
+	t.Chdir(root)
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousStdin, previousWs, previousConfig := os.Stdin, currentWs, currentConfig
+	previousRegistry, previousJournal := toolRegistry, opJournal
+	previousYes, previousPermanent := confirmDelete, permanentDelete
+	os.Stdin, currentWs, currentConfig, toolRegistry, opJournal = stdin, nil, nil, nil, nil
+	t.Cleanup(func() {
+		_ = stdin.Close()
+		os.Stdin, currentWs, currentConfig = previousStdin, previousWs, previousConfig
+		toolRegistry, opJournal = previousRegistry, previousJournal
+		confirmDelete, permanentDelete = previousYes, previousPermanent
+	})
+}
+
+func TestDeleteFileYesSkipsPassword(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	isolateDelete(t, root)
+
+	path := filepath.Join(root, "out.txt")
+	if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// without --yes the password cannot be asked for, so nothing is deleted
+	confirmDelete = false
+	err = deleteFile(deleteFileCmd, []string{"out.txt"})
+	if err == nil || !strings.Contains(err.Error(), "without confirmation") {
+		t.Fatalf("deleteFile() without --yes = %v, want a refusal", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("deleteFile() without --yes removed the file: %v", err)
+	}
+
+	// --yes is the documented exception, it deletes without the password check
+	confirmDelete = true
+	if err := deleteFile(deleteFileCmd, []string{"out.txt"}); err != nil {
+		t.Fatalf("deleteFile() with --yes = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("deleteFile() with --yes left the file, stat error %v", err)
+	}
+
+	// the password verifier was never consulted, so it recorded no attempt
+	state, err := os.ReadDir(os.Getenv("XDG_STATE_HOME"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if len(state) != 0 {
+		t.Errorf("deleteFile() with --yes wrote auth state %v", state)
+	}
+}
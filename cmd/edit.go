@@ -0,0 +1,386 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/components/diffview"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/diff"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/journal"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// edit input modes, auto tells them apart by their markers
+const (
+	editAuto    = "auto"
+	editReplace = "replace"
+	editContent = "content"
+	editDiff    = "diff"
+)
+
+var editModes = []string{editAuto, editReplace, editContent, editDiff}
+
+// errChangedOnDisk - the file no longer holds the content a change was made against
+var errChangedOnDisk = errors.New("file changed since it was read")
+
+var (
+	editInput  string
+	editMode   string
+	editYes    bool
+	editDryRun bool
+)
+
+// editArgs - arguments of the edit tool, exactly one of content, patch and edits
+type editArgs struct {
+	Path    string             `json:"path"`
+	Content *string            `json:"content,omitempty"`
+	Patch   string             `json:"patch,omitempty"`
+	Edits   []diff.Replacement `json:"edits,omitempty"`
+	DryRun  bool               `json:"dry_run,omitempty"`
+
+	// BaseHash - journal.Hash of the content the change was made against, e.g. the reviewed one
+	BaseHash string `json:"base_sha256,omitempty"`
+}
+
+// editCmd - change an existing file
+var editCmd = &cobra.Command{
+	Use:   "edit file",
+	Short: "Change an existing file after reviewing the diff",
+	Long: `Change an existing file with search/replace blocks, its full new content or a unified diff, read from --input or stdin.
+Search/replace blocks look like
+
+  <<<<<<< SEARCH
+  old lines
+  =======
+  new lines
+  >>>>>>> REPLACE
+
+and each search text must be in the file exactly once. On a terminal every hunk of the resulting diff is accepted or rejected
+before the file is written, --yes writes all of them. The file is replaced atomically and the edit can be rolled back with 'agent-code undo'.`,
+	Example: `  agent-code edit main.go --input change.txt
+  git diff | agent-code edit main.go --yes
+  agent-code edit --dry-run --mode=content README.MD < README.new`,
+	Args: cobra.ExactArgs(1),
+	RunE: editFile,
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	editCmd.Flags().StringVarP(&editInput, "input", "i", "", "file holding the change, - or none for stdin")
+	editCmd.Flags().StringVarP(&editMode, "mode", "m", editAuto, fmt.Sprintf("how to read the change, one of %s", strings.Join(editModes, ", ")))
+	editCmd.Flags().BoolVarP(&editYes, "yes", "y", false, "write every hunk without reviewing them")
+	editCmd.Flags().BoolVar(&editDryRun, "dry-run", false, "print the diff without writing the file")
+}
+
+func editFile(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(editModes, editMode) {
+		return validationError(fmt.Errorf("invalid --mode value %q. allowed: %s", editMode, strings.Join(editModes, ", ")))
+	}
+
+	path, before, err := readEditable(args[0])
+	if err != nil {
+		return validationError(err)
+	}
+
+	input, err := readEditInput()
+	if err != nil {
+		return err
+	}
+
+	toolArgs, err := parseEditInput(args[0], input, editMode)
+	if err != nil {
+		return validationError(err)
+	}
+
+	after, err := editedContent(before, toolArgs)
+	if err != nil {
+		return validationError(err)
+	}
+
+	a, b := diff.SplitLines(before), diff.SplitLines(after)
+	hunks := diff.Compute(a, b, diff.DefaultContext)
+	if len(hunks) == 0 {
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("no changes to %s", args[0])))
+		return nil
+	}
+
+	if editDryRun {
+		printDiff(diff.Unified("a/"+args[0], "b/"+args[0], hunks))
+		return nil
+	}
+
+	// review the hunks one by one, only the accepted ones are written
+	if !editYes {
+		if !canPrompt() || !canRender() {
+			return validationError(fmt.Errorf("refusing to edit %s without review. pass --yes, or --input so the hunks can be reviewed on the terminal", args[0]))
+		}
+
+		view := diffview.InitialDiffViewModel(args[0], hunks)
+		if _, err := tea.NewProgram(view, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run(); err != nil {
+			return err
+		}
+		if view.Cancelled() || !view.Confirmed() {
+			return cancelledError("Edit operation cancelled.")
+		}
+
+		accept := view.Accepted()
+		if !slices.Contains(accept, true) {
+			return cancelledError("No hunks accepted, nothing written.")
+		}
+		after = strings.Join(diff.Select(a, hunks, accept), "")
+	}
+
+	// the file may have changed during the review, the tool refuses to write over that
+	result, err := getToolRegistry().Call(cmd.Context(), editFileTool, editArgs{Path: path, Content: &after, BaseHash: journal.Hash([]byte(before))})
+	if err != nil {
+		return err
+	}
+
+	summary, patch, _ := strings.Cut(result, "\n")
+	printDiff(patch)
+	fmt.Println(ui.RenderSuccess(summary))
+	return nil
+}
+
+// readEditable - resolved path and content of a text file that can be edited
+func readEditable(fileName string) (string, string, error) {
+	path, err := resolveWritable(fileName)
+	if err != nil {
+		return "", "", err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", "", fmt.Errorf("file %s does not exist, use create for new files", fileName)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error accessing %s: %w", fileName, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", "", fmt.Errorf("%s is not a regular file", fileName)
+	}
+
+	content, err := filesystem.Sniff(path)
+	if err != nil {
+		return "", "", err
+	}
+	if content.Binary() {
+		return "", "", fmt.Errorf("%w: %s cannot be edited", filesystem.ErrBinary, fileName)
+	}
+	if content.Encoding != filesystem.EncodingUTF8 && content.Encoding != filesystem.EncodingUTF8BOM {
+		return "", "", fmt.Errorf("%s is %s, only UTF-8 files can be edited", fileName, content.Encoding)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	return path, string(data), nil
+}
+
+// readEditInput - the change from --input, or stdin when it is piped
+func readEditInput() (string, error) {
+	var reader io.Reader = os.Stdin
+	switch {
+	case editInput != "" && editInput != "-":
+		path, err := resolvePath(editInput)
+		if err != nil {
+			return "", validationError(err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return "", validationError(fmt.Errorf("error reading %s: %w", editInput, err))
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		reader = file
+	case editInput == "" && canPrompt():
+		return "", validationError(fmt.Errorf("pass the change with --input or on stdin"))
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("error reading the change: %w", err)
+	}
+	return string(data), nil
+}
+
+// parseEditInput - the change as edit tool arguments, auto picks blocks, then a diff, then full content
+func parseEditInput(fileName, input, mode string) (editArgs, error) {
+	args := editArgs{Path: fileName}
+
+	if mode == editAuto {
+		switch {
+		case diff.HasBlocks(input):
+			mode = editReplace
+		case looksLikeDiff(input):
+			mode = editDiff
+		default:
+			mode = editContent
+		}
+	}
+
+	switch mode {
+	case editReplace:
+		edits, err := diff.ParseBlocks(input)
+		if err != nil {
+			return args, err
+		}
+		args.Edits = edits
+	case editDiff:
+		args.Patch = input
+	default:
+		args.Content = &input
+	}
+
+	return args, nil
+}
+
+// looksLikeDiff - a line starts a hunk and parses as one
+func looksLikeDiff(input string) bool {
+	if !strings.HasPrefix(input, "@@") && !strings.Contains(input, "\n@@") {
+		return false
+	}
+	_, err := diff.Parse(input)
+	return err == nil
+}
+
+// editedContent - before with the change of args made
+func editedContent(before string, args editArgs) (string, error) {
+	given := 0
+	if args.Content != nil {
+		given++
+	}
+	if args.Patch != "" {
+		given++
+	}
+	if len(args.Edits) > 0 {
+		given++
+	}
+	if given != 1 {
+		return "", fmt.Errorf("exactly one of content, patch and edits is needed")
+	}
+
+	switch {
+	case args.Content != nil:
+		return *args.Content, nil
+	case len(args.Edits) > 0:
+		return diff.Replace(before, args.Edits)
+	}
+
+	files, err := diff.Parse(args.Patch)
+	if err != nil {
+		return "", err
+	}
+	if len(files) != 1 {
+		return "", fmt.Errorf("the patch changes %d files, edit takes one", len(files))
+	}
+
+	after, _, err := diff.Apply(diff.SplitLines(before), files[0].Hunks, diff.DefaultFuzz)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(after, ""), nil
+}
+
+// printDiff - the diff coloured on a terminal, plain when piped
+func printDiff(patch string) {
+	if patch == "" {
+		return
+	}
+	if canRender() {
+		patch = ui.RenderDiff(patch)
+	}
+	fmt.Print(patch)
+}
+
+// editFileFromTool - change a file and return a summary line followed by the diff
+func editFileFromTool(ctx context.Context, data json.RawMessage) (string, error) {
+	var args editArgs
+	if err := json.Unmarshal(data, &args); err != nil {
+		return "", fmt.Errorf("invalid tool arguments: %w", err)
+	}
+
+	path, before, err := readEditable(args.Path)
+	if err != nil {
+		return "", err
+	}
+	if args.BaseHash != "" && journal.Hash([]byte(before)) != args.BaseHash {
+		return "", fmt.Errorf("%w: %s, read it again and redo the change", errChangedOnDisk, args.Path)
+	}
+
+	after, err := editedContent(before, args)
+	if err != nil {
+		return "", err
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return "", err
+	}
+	rel := ws.Rel(path)
+
+	hunks := diff.Compute(diff.SplitLines(before), diff.SplitLines(after), diff.DefaultContext)
+	if len(hunks) == 0 {
+		return fmt.Sprintf("no changes to %s", rel), nil
+	}
+
+	added, removed := 0, 0
+	for _, hunk := range hunks {
+		a, r := hunk.Stats()
+		added += a
+		removed += r
+	}
+	patch := diff.Unified("a/"+rel, "b/"+rel, hunks)
+
+	if args.DryRun {
+		return fmt.Sprintf("would edit %s (+%d -%d)\n%s", rel, added, removed, patch), nil
+	}
+
+	entry, err := writeEdit(path, before, after)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("edited %s (+%d -%d), undo with `agent-code undo %d`\n%s", rel, added, removed, entry.ID, patch), nil
+}
+
+// writeEdit - replace the content of an existing file keeping its permissions, recorded in the journal
+func writeEdit(path, before, after string) (*journal.Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// whatever changed the file since before was read is not overwritten, nor recorded as the old content
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if string(current) != before {
+		return nil, fmt.Errorf("%w: %s, nothing written", errChangedOnDisk, path)
+	}
+	if err := filesystem.WriteFile(path, []byte(after), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	j, err := getJournal()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := j.RecordEdit(path, []byte(before), []byte(after))
+	if err != nil {
+		return nil, fmt.Errorf("%s edited but not recorded in the journal: %w", path, err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"os"
+)
+
+// process exit codes
+const (
+	ExitOK         = 0 // command succeeded
+	ExitError      = 1 // unexpected runtime failure
+	ExitValidation = 2 // invalid input, path or flag value
+	ExitCancelled  = 3 // user cancelled the operation
+)
+
+// exitError - error carrying the process exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// validationError - wrap an input/path validation failure
+func validationError(err error) error {
+	return &exitError{code: ExitValidation, err: err}
+}
+
+// cancelledError - user quit the operation
+func cancelledError(message string) error {
+	return &exitError{code: ExitCancelled, err: errors.New(message)}
+}
+
+// exitCode - exit code for an error returned by a command
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	return ExitError
+}
+
+// printExitError - print a command error to stderr according to its kind
+func printExitError(err error) {
+	switch exitCode(err) {
+	case ExitCancelled:
+		_, _ = fmt.Fprintf(os.Stderr, "\n ❌%s\n", err.Error())
+	default:
+		_, _ = fmt.Fprintln(os.Stderr, ui.RenderError(err.Error()))
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/journal"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	historyJSON bool
+	undoTo      int
+	opJournal   *journal.Journal
+)
+
+// historyCmd - list the recorded mutations
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the files created, deleted and edited, newest first",
+	Long: `Every create, delete and edit, from the commands and from agent tool calls, is recorded in the journal under .agent-code/journal
+with its time and the session (run) it happened in. Entries are rolled back with 'agent-code undo'.`,
+	Args: cobra.NoArgs,
+	RunE: listHistory,
+}
+
+// undoCmd - roll back recorded mutations
+var undoCmd = &cobra.Command{
+	Use:   "undo [id]",
+	Short: "Undo the latest operation, a single one by id, or every operation back to --to",
+	Long: `Undo the latest operation that is not undone yet: a created file is removed, a deleted path is restored from the trash
+and an edited file gets its previous content back. With a history id only that operation is undone, later ones are kept.
+With --to every operation from the newest down to that history id is undone.
+An operation is refused when its file changed since, undo stops there and keeps what was undone so far.
+Permanent deletes are listed but cannot be undone, they are passed over.`,
+	Example: `  agent-code undo
+  agent-code undo 12
+  agent-code undo --to 12`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: undo,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd, undoCmd)
+
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "print the entries as JSON")
+	undoCmd.Flags().IntVar(&undoTo, "to", 0, "history id to undo back to, including it")
+}
+
+// getJournal - journal of the workspace, entries of this run share one session id
+func getJournal() (*journal.Journal, error) {
+	if opJournal != nil {
+		return opJournal, nil
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	opJournal = journal.ForWorkspace(ws.Root, journal.NewSession())
+	return opJournal, nil
+}
+
+func listHistory(cmd *cobra.Command, args []string) error {
+	j, err := getJournal()
+	if err != nil {
+		return err
+	}
+
+	entries, err := j.List()
+	if err != nil {
+		return err
+	}
+
+	if historyJSON {
+		if entries == nil {
+			entries = []journal.Entry{}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(ui.RenderInfo("history is empty"))
+		return nil
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		status := ""
+		switch {
+		case entry.Undone():
+			status = ui.InfoStyle.Render("(undone)")
+		case entry.Permanent():
+			status = ui.InfoStyle.Render("(permanent)")
+		}
+
+		fmt.Printf("%s  %s  %s  %-6s  %s %s\n",
+			ui.InfoStyle.Width(5).Render(fmt.Sprint(entry.ID)),
+			entry.Time.Local().Format(time.DateTime),
+			entry.Session,
+			entry.Op,
+			ui.TextStyle.Render(ws.Rel(entry.Path)),
+			status,
+		)
+	}
+
+	return nil
+}
+
+func undo(cmd *cobra.Command, args []string) error {
+	j, err := getJournal()
+	if err != nil {
+		return err
+	}
+
+	if undoTo > 0 {
+		if _, err := j.Get(undoTo); err != nil {
+			return validationError(err)
+		}
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		if undoTo > 0 {
+			return validationError(fmt.Errorf("pass either a history id or --to, not both"))
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil || id <= 0 {
+			return validationError(fmt.Errorf("invalid history id %q", args[0]))
+		}
+		entry, err := j.Get(id)
+		if err != nil {
+			return validationError(err)
+		}
+		return undoEntries(j, ws, []journal.Entry{*entry})
+	}
+
+	entries, err := j.List()
+	if err != nil {
+		return err
+	}
+
+	// newest first, down to --to or just the latest one. permanent deletes cannot be undone
+	// and are passed over, undoing what came before them still refuses to clash with them
+	var pending []journal.Entry
+	for _, entry := range entries {
+		if entry.Undone() || entry.Permanent() || (undoTo > 0 && entry.ID < undoTo) {
+			continue
+		}
+		pending = append(pending, entry)
+		if undoTo == 0 {
+			break
+		}
+	}
+
+	if len(pending) == 0 {
+		fmt.Println(ui.RenderInfo("nothing to undo"))
+		return nil
+	}
+
+	return undoEntries(j, ws, pending)
+}
+
+// undoEntries - roll back entries in the order given, stopping at the first that is refused
+func undoEntries(j *journal.Journal, ws *workspace.Workspace, pending []journal.Entry) error {
+	for _, entry := range pending {
+		if err := j.Undo(&entry); err != nil {
+			if errors.Is(err, journal.ErrChanged) || errors.Is(err, journal.ErrNotUndoable) || errors.Is(err, journal.ErrForeign) {
+				return validationError(fmt.Errorf("cannot undo %d (%s %s): %w", entry.ID, entry.Op, ws.Rel(entry.Path), err))
+			}
+			return err
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Undid %d: %s %s", entry.ID, entry.Op, ws.Rel(entry.Path))))
+	}
+
+	return nil
+}
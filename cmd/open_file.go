@@ -1,24 +1,33 @@
 package cmd
 
 import (
-	"bufio"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/components/fileview"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/components/listinput"
-	"github.com/nathanmbicho/agent-code-assignment/pkg/components/textinput"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/editor"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
 	"github.com/spf13/cobra"
+	"io"
 	"os"
+	"os/exec"
 	"strings"
 )
 
+// maxHexDump - bytes of a binary file printed when it cannot be paged through
+const maxHexDump = 1024
+
 var (
 	openFileName    string
 	showLineNumbers bool
-)
+	openWith        string
+	openLine        int
+	openColumn      int
 
-type InputOptions struct {
-	FileName *textinput.Output
-}
+	editorRegistry *editor.Registry
+)
 
 type ListOptions struct {
 	ListOptions *listinput.Selection
@@ -26,39 +35,118 @@ type ListOptions struct {
 
 // openFileCmd - one file
 var openFileCmd = &cobra.Command{
-	Use:   "open",
+	Use:   "open [file]",
 	Short: "Open the file in the current directory",
-	Long:  `Open the file in the current specified directory. File opened must exist in the current directory and will open on the terminal.`,
-	Run:   openFile,
+	Long: `Open the file in the current specified directory. File opened must exist in the current directory and will open on the terminal.
+Pass the file as an argument and the tool with --with to skip the interactive prompts.`,
+	Example: `  agent-code open --with=default main.go
+  agent-code open --with=nvim --line=42 main.go`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: openFile,
 }
 
 func init() {
 	rootCmd.AddCommand(openFileCmd)
+
+	openFileCmd.Flags().StringVarP(&openWith, "with", "w", "", "tool to open the file with, one of open.editors in the config, $VISUAL or $EDITOR (prompted when omitted, the first one when not a terminal)")
+	openFileCmd.Flags().IntVar(&openLine, "line", 0, "line to open the file at")
+	openFileCmd.Flags().IntVar(&openColumn, "column", 0, "column to open the file at, with --line")
 }
 
-func openFile(cmd *cobra.Command, args []string) {
-	//input command
-	inputOptions := InputOptions{
-		FileName: &textinput.Output{},
+// getEditors - editors of the config plus $VISUAL and $EDITOR, built once per run
+func getEditors() (*editor.Registry, error) {
+	if editorRegistry != nil {
+		return editorRegistry, nil
 	}
 
-	// handle program create, passing values
-	tProgram := tea.NewProgram(textinput.InitialTextInputModel(
-		inputOptions.FileName,
-		"Enter file name to open ...",
-		func(input string) (bool, error) {
-			return validateSearchFile(input)
-		},
-	))
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
 
-	// run bubbletea program
-	if _, err := tProgram.Run(); err != nil {
-		cobra.CheckErr(err)
-		return
+	registry, err := editor.NewRegistry(cfg.Open.Editors)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	editorRegistry = registry
+	return editorRegistry, nil
+}
+
+func openFile(cmd *cobra.Command, args []string) error {
+	registry, err := getEditors()
+	if err != nil {
+		return err
+	}
+
+	if openLine < 0 || openColumn < 0 {
+		return validationError(fmt.Errorf("--line and --column cannot be negative"))
+	}
+
+	// only editors found on PATH are offered, "default" is the built in viewer
+	listOfOpenFileTools := editor.Names(registry.Available())
+	if len(listOfOpenFileTools) == 0 {
+		return validationError(fmt.Errorf("none of the configured editors are installed, set open.editors in the config or $EDITOR"))
+	}
+
+	if openWith != "" {
+		ed, err := registry.Find(openWith)
+		if err != nil {
+			return validationError(err)
+		}
+		if _, err := ed.Path(); err != nil {
+			return validationError(err)
+		}
+	}
+
+	// file name from argument or prompt
+	if len(args) == 1 {
+		openFileName = args[0]
+		if _, err := validateSearchFile(openFileName); err != nil {
+			return validationError(err)
+		}
+	} else {
+		if !canPrompt() {
+			return validationError(fmt.Errorf("file argument is required when stdin is not a terminal"))
+		}
+
+		// pick from the workspace files instead of typing the path
+		paths, quit, err := pickFiles("Find a file to open ...", false, false, func(path string) error {
+			_, err := validateSearchFile(path)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if quit {
+			return cancelledError("Open file operation cancelled.")
+		}
+
+		openFileName = paths[0]
 	}
 
-	if inputOptions.FileName.Quit {
-		fmt.Println("\n ❌Open file operation cancelled.")
+	// tool from flag, when not a terminal fall back to the default viewer
+	if openWith == "" && !canPrompt() {
+		openWith = listOfOpenFileTools[0]
+	}
+
+	// interactive terminals page through the file in the viewer instead of printing it
+	viewer := canPrompt() && canRender()
+
+	if openWith != "" {
+		if ed, _ := registry.Find(openWith); viewer && ed.Viewer {
+			return viewFile(openFileName)
+		}
+
+		code, _, err := validateOpenFile(openFileName, openWith)
+		if err != nil {
+			return validationError(err)
+		}
+
+		if code != "" {
+			fmt.Println(code)
+		}
+		return nil
 	}
 
 	// list command
@@ -66,34 +154,137 @@ func openFile(cmd *cobra.Command, args []string) {
 		ListOptions: &listinput.Selection{},
 	}
 
-	listOfOpenFileTools := []string{
-		"Default",
-		"Code",
-	}
-
-	tProgram = tea.NewProgram(listinput.InitialListInputModel(
+	tProgram := tea.NewProgram(listinput.InitialListInputModel(
 		listOfOpenFileTools,
-		inputOptions.FileName.Output,
+		openFileName,
 		listOptions.ListOptions,
 		"Select a tool to open with...",
 		func(path, choice string) (string, bool, error) {
+			ed, err := registry.Find(choice)
+			if err != nil {
+				return "", false, err
+			}
+
+			switch {
+			case viewer && ed.Viewer:
+				// the viewer runs once the list is closed
+				_, err := resolveViewFile(path)
+				return "", err == nil, err
+			case ed.Terminal:
+				// run by the list itself, see WithExec
+				_, err := resolvePath(path)
+				return "", err == nil, err
+			}
 			return validateOpenFile(path, choice)
 		},
-	))
+	).WithExec(func(path, choice string) (*exec.Cmd, error) {
+		ed, err := registry.Find(choice)
+		if err != nil || !ed.Terminal {
+			return nil, err
+		}
+		return editorCommand(ed, path)
+	}))
 
 	if _, err := tProgram.Run(); err != nil {
-		cobra.CheckErr(err)
-		return
+		return err
 	}
 
 	if listOptions.ListOptions.Quit {
-		fmt.Println("\n ❌Create file operation cancelled.")
+		return cancelledError("Open file operation cancelled.")
+	}
+
+	if ed, _ := registry.Find(listOptions.ListOptions.Choice); viewer && ed.Viewer {
+		return viewFile(openFileName)
+	}
+
+	return nil
+}
+
+// editorCommand - command opening a workspace file in an editor at --line and --column
+func editorCommand(ed editor.Editor, fileName string) (*exec.Cmd, error) {
+	path, err := resolvePath(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return ed.Cmd(path, openLine, openColumn)
+}
+
+// resolveViewFile - path of a regular file inside the workspace
+func resolveViewFile(fileName string) (string, error) {
+	path, err := resolvePath(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", fileName)
+	}
+
+	return path, nil
+}
+
+// viewFile - open the file in the full screen viewer
+func viewFile(fileName string) error {
+	path, err := resolveViewFile(fileName)
+	if err != nil {
+		return validationError(err)
+	}
+
+	model, err := fileview.InitialFileViewModel(path)
+	if err != nil {
+		return fmt.Errorf("error opening file %s - %w", fileName, err)
 	}
+	defer func() {
+		_ = model.Close()
+	}()
+	model.Goto(openLine)
+
+	// e in the viewer hands the file to $VISUAL, $EDITOR or the first installed editor
+	if registry, err := getEditors(); err == nil {
+		if ed, ok := registry.Preferred(); ok {
+			model.WithEditor(ed.Name, func(line int) (*exec.Cmd, error) {
+				return ed.Cmd(path, line, 0)
+			})
+		}
+	}
+
+	if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run(); err != nil {
+		return err
+	}
+
+	return model.Err()
 }
 
-// display file content in the cli
+// display file content in the cli, binaries as a hex dump of their first bytes
 func displayFileContents(fileName string) (string, error) {
-	// open file
+	lines, err := readFileLines(fileName)
+	if errors.Is(err, filesystem.ErrBinary) {
+		return displayHexDump(fileName)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return numberLines(lines), nil
+}
+
+// numberLines - lines prefixed with their line number
+func numberLines(lines []string) string {
+	list := make([]string, 0, len(lines))
+	for i, line := range lines {
+		list = append(list, fmt.Sprintf("%4d | %s", i+1, line))
+	}
+
+	return strings.Join(list, "\n")
+}
+
+// displayHexDump - size, MIME type and a hex dump of the first maxHexDump bytes
+func displayHexDump(fileName string) (string, error) {
 	file, err := os.Open(fileName)
 	if err != nil {
 		return "", err
@@ -107,21 +298,44 @@ func displayFileContents(fileName string) (string, error) {
 		}
 	}(file)
 
-	scanner := bufio.NewScanner(file)
-	lineNumber := 1
+	content, err := filesystem.Sniff(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	head := make([]byte, min(content.Size, maxHexDump))
+	if _, err := io.ReadFull(file, head); err != nil {
+		return "", err
+	}
 
-	var list []string
-	for scanner.Scan() {
-		line := scanner.Text()
-		text := fmt.Sprintf(fmt.Sprintf("%4d | %s", lineNumber, line))
-		lineNumber++
+	summary := fmt.Sprintf("binary file, %s, %s", filesystem.FormatSize(content.Size), content.MIME)
+	if content.Size > maxHexDump {
+		summary += fmt.Sprintf(", first %d bytes", maxHexDump)
+	}
+
+	return summary + "\n" + strings.TrimSuffix(hex.Dump(head), "\n"), nil
+}
 
-		list = append(list, text)
+// read file content line by line, UTF-16 is decoded and lines can be any length
+func readFileLines(fileName string) ([]string, error) {
+	text, _, err := filesystem.ReadText(fileName)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return "", err
+	return textLines(text), nil
+}
+
+// textLines - text split into lines without their line endings
+func textLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	list := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range list {
+		list[i] = strings.TrimSuffix(line, "\r")
 	}
 
-	return strings.Join(list, "\n"), nil
+	return list
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/components/fuzzyfinder"
+	"os"
+	"path/filepath"
+)
+
+// pickFiles - let the user pick workspace files in the fuzzy finder, paths are relative to the
+// current directory like typed ones. quit is true when the finder was closed without a pick
+func pickFiles(header string, multi, dirs bool, validate func(path string) error) ([]string, bool, error) {
+	ws, err := getWorkspace()
+	if err != nil {
+		return nil, false, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// typed paths are relative to the current directory, so picked ones are too
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			return rel
+		}
+		return path
+	}
+
+	output := &fuzzyfinder.Output{}
+	finder := fuzzyfinder.InitialFuzzyFinderModel(output, ws.Root, header, func(paths []string) (bool, error) {
+		if validate == nil {
+			return true, nil
+		}
+		for _, path := range paths {
+			if err := validate(relative(path)); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	if multi {
+		finder.WithMulti()
+	}
+	if dirs {
+		finder.WithDirs()
+	}
+
+	if _, err := tea.NewProgram(finder, tea.WithAltScreen()).Run(); err != nil {
+		return nil, false, err
+	}
+	if output.Quit {
+		return nil, true, nil
+	}
+
+	paths := make([]string, 0, len(output.Paths))
+	for _, path := range output.Paths {
+		paths = append(paths, relative(path))
+	}
+	return paths, false, nil
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+)
+
+var modelName string
+
+// newProvider - model provider from the config, --model overrides the configured model
+func newProvider() (provider.Provider, error) {
+	settings, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := settings.Provider()
+	if modelName != "" {
+		cfg.Model = modelName
+	}
+
+	return provider.New(cfg)
+}
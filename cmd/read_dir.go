@@ -1,25 +1,87 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/walker"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
+	"time"
 )
 
 var (
-	dirPath    string
-	showHidden bool
+	dirPath      string
+	showHidden   bool
+	readDepth    int
+	readInclude  []string
+	readExclude  []string
+	readNoIgnore bool
+	readDirsOnly bool
+	readFormat   string
+	readFlat     bool
+	readLong     bool
+	readSizes    bool
+	readFollow   bool
 )
 
+// output formats of read, anything but tree is unstyled and meant for scripts
+const (
+	formatTree   = "tree"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatYAML   = "yaml"
+)
+
+var readFormats = []string{formatTree, formatJSON, formatNDJSON, formatYAML}
+
+// dirEntry - one entry of the machine readable read output
+type dirEntry struct {
+	Path    string    `json:"path" yaml:"path"` // relative to the workspace root
+	Type    string    `json:"type" yaml:"type"`
+	Size    int64     `json:"size" yaml:"size"`
+	Mode    string    `json:"mode" yaml:"mode"`
+	ModTime time.Time `json:"mod_time" yaml:"mod_time"`
+	Owner   string    `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Target  string    `json:"target,omitempty" yaml:"target,omitempty"`
+
+	// with --sizes, directories only
+	TotalSize int64 `json:"total_size,omitempty" yaml:"total_size,omitempty"`
+	Files     int   `json:"files,omitempty" yaml:"files,omitempty"`
+
+	// with --long
+	GitStatus string `json:"git_status,omitempty" yaml:"git_status,omitempty"`
+
+	Error    string      `json:"error,omitempty" yaml:"error,omitempty"`
+	Cycle    bool        `json:"cycle,omitempty" yaml:"cycle,omitempty"`
+	Children []*dirEntry `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
 var readDirCmd = &cobra.Command{
-	Use:   "read",
+	Use:   "read [path]",
 	Short: "Read directory and list its content",
-	Long:  `Read directory and list its content, both its files and other directories in tree like structure.`,
-	Run:   readDirectory,
+	Long: `Read directory and list its content, both its files and other directories in tree like structure.
+Entries matched by .gitignore and .ignore files are left out unless --no-ignore is set.
+--include and --exclude take gitignore style globs: *.go matches at any depth, cmd/*.go only below cmd.`,
+	Example: `  agent-code read pkg
+  agent-code read --depth=2 --exclude=vendor --include='*.go'
+  agent-code read --dirs-only
+  agent-code read --long --sizes --depth=1
+  agent-code read --format=ndjson --include='*.go' | jq -r .path`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: readDirectory,
 }
 
 func init() {
@@ -27,74 +89,240 @@ func init() {
 
 	readDirCmd.Flags().StringVarP(&dirPath, "path", "p", ".", "path name with current directory as default")
 	readDirCmd.Flags().BoolVarP(&showHidden, "all", "a", false, "show hidden files and directories")
-	readDirCmd.MarkFlagRequired("file")
+	readDirCmd.Flags().IntVarP(&readDepth, "depth", "L", 0, "levels of directories to descend, 0 for no limit")
+	readDirCmd.Flags().StringSliceVarP(&readInclude, "include", "I", nil, "only list files matching these globs, repeatable or comma separated")
+	readDirCmd.Flags().StringSliceVarP(&readExclude, "exclude", "X", nil, "leave out files and directories matching these globs")
+	readDirCmd.Flags().BoolVar(&readNoIgnore, "no-ignore", false, "list entries matched by .gitignore and .ignore files too")
+	readDirCmd.Flags().BoolVarP(&readDirsOnly, "dirs-only", "d", false, "list directories only")
+	readDirCmd.Flags().StringVarP(&readFormat, "format", "f", formatTree, fmt.Sprintf("output format, one of %s", strings.Join(readFormats, ", ")))
+	readDirCmd.Flags().BoolVar(&readFlat, "flat", false, "list json and yaml entries flat instead of nested, ndjson is always flat")
+	readDirCmd.Flags().BoolVarP(&readLong, "long", "l", false, "show permissions, owner, size, modification time and git status")
+	readDirCmd.Flags().BoolVarP(&readSizes, "sizes", "s", false, "show file sizes and the total size and file count of directories")
+	readDirCmd.Flags().BoolVar(&readFollow, "follow-symlinks", false, "descend into symlinked directories inside the workspace, loops are detected and skipped")
 }
 
-func readDirectory(cmd *cobra.Command, args []string) {
-	fmt.Printf("\n")
+func readDirectory(cmd *cobra.Command, args []string) error {
+	// positional path takes precedence over --path
+	if len(args) == 1 {
+		dirPath = args[0]
+	}
+
+	// resolve the path inside the workspace
+	path, err := resolvePath(dirPath)
+	if err != nil {
+		return validationError(err)
+	}
+
+	if readDepth < 0 {
+		return validationError(fmt.Errorf("--depth cannot be negative"))
+	}
+
+	if !slices.Contains(readFormats, readFormat) {
+		return validationError(fmt.Errorf("invalid --format value %q. allowed: %s", readFormat, strings.Join(readFormats, ", ")))
+	}
 
 	// check if the path exists
-	fileInfo, err := os.Stat(dirPath)
+	fileInfo, err := os.Stat(path)
 	if os.IsNotExist(err) {
-		fmt.Printf("error - path %s does not exist \n", dirPath)
-		return
+		return validationError(fmt.Errorf("path %s does not exist", dirPath))
+	}
+	if err != nil {
+		return fmt.Errorf("error accessing path %s: %w", dirPath, err)
 	}
 
 	// check if the path is a valid directory
 	if !fileInfo.IsDir() {
-		fmt.Printf("error - path %s is an ivalid directory \n", dirPath)
-		return
+		return validationError(fmt.Errorf("path %s is an invalid directory", dirPath))
 	}
 
-	path, err := filepath.Abs(dirPath)
-	if err != nil {
-		fmt.Printf("error getting path %v \n", path)
-		return
+	// Ctrl+C stops the walk, large trees take a while
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	// styles only apply to the tree
+	if readFormat == formatTree {
+		ctx = withStyledOutput(ctx)
 	}
 
-	fmt.Printf("absolute path %s\n", ui.RenderSuccess(path))
+	var progress *walkProgressBar
+	if isTerminal(os.Stderr) {
+		progress = newWalkProgressBar(os.Stderr)
+		ctx = withWalkProgress(ctx, progress.update)
+	}
 
 	// print directory details
-	err = printDirectory(path, "")
+	tree, err := getToolRegistry().Call(ctx, listDirectoryTool, pathArgs{
+		Path:       path,
+		ShowHidden: showHidden,
+		Depth:      readDepth,
+		Include:    readInclude,
+		Exclude:    readExclude,
+		NoIgnore:   readNoIgnore,
+		DirsOnly:   readDirsOnly,
+		Format:     readFormat,
+		Flat:       readFlat,
+		Long:       readLong,
+		Sizes:      readSizes,
+		Follow:     readFollow,
+	})
+	if progress != nil {
+		progress.clear()
+	}
+	if errors.Is(err, context.Canceled) {
+		return cancelledError("Read operation cancelled.")
+	}
+	if errors.Is(err, walker.ErrInvalidPattern) {
+		return validationError(err)
+	}
 	if err != nil {
-		fmt.Printf("error getting dir contents %v \n", path)
-		return
+		return err
+	}
+
+	if readFormat != formatTree {
+		fmt.Print(tree)
+		return nil
 	}
 
 	fmt.Printf("\n")
+
+	fmt.Printf("absolute path %s\n", ui.RenderSuccess(path))
+
+	fmt.Print(tree)
+
+	fmt.Printf("\n")
+	return nil
 }
 
-// print directory contents
-func printDirectory(dirPath, prefix string) error {
-	// read directory contents
-	entries, err := os.ReadDir(dirPath)
+// walkProgressBar - directories read out of those found so far, shown once a walk takes a moment
+type walkProgressBar struct {
+	w     io.Writer
+	last  time.Time
+	shown bool
+}
+
+// walkProgressRedraw - the bar is drawn this long after the walk starts and redrawn at most this often
+const walkProgressRedraw = 100 * time.Millisecond
+
+func newWalkProgressBar(w io.Writer) *walkProgressBar {
+	return &walkProgressBar{w: w, last: time.Now()}
+}
+
+func (p *walkProgressBar) update(done, found int) {
+	now := time.Now()
+	if now.Sub(p.last) < walkProgressRedraw {
+		return
+	}
+	p.last, p.shown = now, true
+
+	_, _ = fmt.Fprintf(p.w, "\r%s %s", ui.RenderProgressBar(done, found, 30), ui.InfoStyle.Render(fmt.Sprintf("%d/%d directories", done, found)))
+}
+
+// clear - erase the bar before the tree is printed
+func (p *walkProgressBar) clear() {
+	if p.shown {
+		_, _ = fmt.Fprint(p.w, "\r\x1b[K")
+	}
+}
+
+// formatDirectory - the walked tree as json, ndjson or yaml, paths relative to the workspace root
+func formatDirectory(tree *walker.Node, format string, flat bool, opts treeOptions) (string, error) {
+	ws, err := getWorkspace()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// filter hidden files if not showing them
-	var filteredEntries []os.DirEntry
-	for _, entry := range entries {
-		if !showHidden && strings.HasPrefix(entry.Name(), ".") {
-			continue
+	root := newDirEntry(ws, tree, !flat && format != formatNDJSON, opts)
+
+	var entries []*dirEntry
+	if flat || format == formatNDJSON {
+		for _, node := range tree.Flatten() {
+			entries = append(entries, newDirEntry(ws, node, false, opts))
 		}
-		filteredEntries = append(filteredEntries, entry)
 	}
 
-	// sort entries - directories first, then files
-	sort.Slice(filteredEntries, func(i, j int) bool {
-		if filteredEntries[i].IsDir() && !filteredEntries[j].IsDir() {
-			return true
+	var buf bytes.Buffer
+	switch format {
+	case formatNDJSON:
+		encoder := json.NewEncoder(&buf)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return "", err
+			}
 		}
-		if !filteredEntries[i].IsDir() && filteredEntries[j].IsDir() {
-			return false
+	case formatJSON:
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		var value any = root
+		if flat {
+			value = entries
 		}
-		return filteredEntries[i].Name() < filteredEntries[j].Name()
-	})
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+	case formatYAML:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		var value any = root
+		if flat {
+			value = entries
+		}
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+		if err := encoder.Close(); err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
 
+// newDirEntry - entry of a node, with its children when nested. the path is relative to the
+// workspace root in every format
+func newDirEntry(ws *workspace.Workspace, node *walker.Node, nested bool, opts treeOptions) *dirEntry {
+	entry := &dirEntry{
+		Path:      filepath.ToSlash(ws.Rel(node.Path)),
+		Type:      node.Type(),
+		Size:      node.Size,
+		Mode:      fmt.Sprintf("%04o", node.Mode.Perm()),
+		ModTime:   node.ModTime,
+		Owner:     node.Owner,
+		Target:    node.Target,
+		GitStatus: strings.TrimSpace(opts.git[node.Path]),
+		Cycle:     node.Cycle,
+	}
+	if node.Err != nil {
+		entry.Error = node.Err.Error()
+	}
+	if opts.sizes && node.IsDir {
+		entry.TotalSize = node.TotalSize
+		entry.Files = node.Files
+	}
+
+	if nested {
+		for _, child := range node.Children {
+			entry.Children = append(entry.Children, newDirEntry(ws, child, true, opts))
+		}
+	}
+
+	return entry
+}
+
+// treeOptions - how printDirectory renders the tree
+type treeOptions struct {
+	styled bool // lipgloss colours, off for plain text consumers
+	long   bool // permissions, owner, size, modification time and git status columns
+	sizes  bool // aggregated directory sizes and file counts
+
+	git        map[string]string // git status by absolute path, with long
+	ownerWidth int
+}
+
+// print directory contents
+func printDirectory(w io.Writer, node *walker.Node, prefix string, opts treeOptions) {
 	// loop entries creating a tree like directory design
-	for i, entry := range filteredEntries {
-		isLast := i == len(filteredEntries)-1
+	for i, entry := range node.Children {
+		isLast := i == len(node.Children)-1
 
 		var connector, childPrefix string
 		if isLast {
@@ -105,21 +333,109 @@ func printDirectory(dirPath, prefix string) error {
 			childPrefix = prefix + "│   "
 		}
 
-		name := entry.Name()
-		if entry.IsDir() {
+		name := entry.Name
+		if entry.IsDir {
 			name += "/"
 		}
-		fmt.Printf("%s%s%s\n", prefix, ui.InfoStyle.Render(connector), ui.TextStyle.Render(name))
+		if entry.Target != "" {
+			name += " -> " + entry.Target
+		}
+		if opts.styled {
+			connector = ui.InfoStyle.Render(connector)
+			name = ui.TextStyle.Render(name)
+		}
 
-		// recursively print subdirectories
-		if entry.IsDir() {
-			subPath := filepath.Join(dirPath, entry.Name())
-			err := printDirectory(subPath, childPrefix)
-			if err != nil {
-				fmt.Printf("%s%s[Error: %v]\n", childPrefix, "├── ", err)
+		columns, suffix := "", ""
+		if opts.long {
+			columns = longColumns(entry, opts)
+		} else if opts.sizes {
+			suffix = " " + sizeSummary(entry, opts)
+		}
+
+		// entries that were not followed say why on the same line
+		var note string
+		switch {
+		case entry.Cycle:
+			note = "[cycle, not followed]"
+		case entry.Err != nil && !entry.IsDir:
+			note = fmt.Sprintf("[Error: %v]", entry.Err)
+		}
+		if note != "" {
+			if opts.styled {
+				note = ui.ErrorStyle.Margin(0).Render(note)
 			}
+			suffix += " " + note
+		}
+		_, _ = fmt.Fprintf(w, "%s%s%s%s%s\n", columns, prefix, connector, name, suffix)
+
+		// subdirectories, a directory that could not be read shows its error
+		if entry.IsDir && !entry.Cycle {
+			if entry.Err != nil {
+				_, _ = fmt.Fprintf(w, "%s%s%s[Error: %v]\n", strings.Repeat(" ", lipgloss.Width(columns)), childPrefix, "└── ", entry.Err)
+				continue
+			}
+			printDirectory(w, entry, childPrefix, opts)
 		}
 	}
+}
 
-	return nil
+// longColumns - drwxr-xr-x  owner      1.2 KiB  2026-01-02 15:04  M  before the tree
+func longColumns(node *walker.Node, opts treeOptions) string {
+	size := filesystem.FormatSize(node.Size)
+	if node.IsDir {
+		// the size of the directory entry itself means nothing to most people
+		size = "-"
+		if opts.sizes {
+			size = filesystem.FormatSize(node.TotalSize)
+		}
+	}
+
+	git := opts.git[node.Path]
+	if git == "" {
+		git = "  "
+	}
+
+	columns := fmt.Sprintf("%s  %-*s  %10s  %s  %s  ", node.Mode.String(), opts.ownerWidth, node.Owner, size, node.ModTime.Format("2006-01-02 15:04"), git)
+	if opts.styled {
+		columns = ui.InfoStyle.Render(columns)
+	}
+	return columns
+}
+
+// sizeSummary - (1.2 KiB) for a file, (3.4 MiB, 12 files) for a directory
+func sizeSummary(node *walker.Node, opts treeOptions) string {
+	summary := fmt.Sprintf("(%s)", filesystem.FormatSize(node.Size))
+	if node.IsDir {
+		summary = fmt.Sprintf("(%s, %d %s)", filesystem.FormatSize(node.TotalSize), node.Files, plural(node.Files, "file", "files"))
+	}
+	if opts.styled {
+		summary = ui.InfoStyle.Render(summary)
+	}
+	return summary
+}
+
+// treeSummary - 3 directories, 12 files, with sizes the file count and size of everything below
+func treeSummary(tree *walker.Node, opts treeOptions) string {
+	files, dirs := tree.Count()
+	summary := fmt.Sprintf("%d %s, %d %s", dirs, plural(dirs, "directory", "directories"), files, plural(files, "file", "files"))
+	if opts.sizes {
+		summary += fmt.Sprintf(" listed, %d %s and %s in total", tree.Files, plural(tree.Files, "file", "files"), filesystem.FormatSize(tree.TotalSize))
+	}
+	return summary
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// ownerWidth - widest owner name of the tree, to align the long columns
+func ownerWidth(tree *walker.Node) int {
+	width := 0
+	for _, node := range tree.Flatten() {
+		width = max(width, len(node.Owner))
+	}
+	return width
 }
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/walker"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// entryPaths - the paths of entry and everything nested below it
+func entryPaths(entry *dirEntry) []string {
+	paths := []string{entry.Path}
+	for _, child := range entry.Children {
+		paths = append(paths, entryPaths(child)...)
+	}
+	return paths
+}
+
+func TestFormatDirectoryPaths(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"main.go", "cmd/x.go", "cmd/sub/y.go"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous := currentWs
+	currentWs = &workspace.Workspace{Root: root}
+	t.Cleanup(func() { currentWs = previous })
+
+	// listed from the root and from a directory below it, paths stay relative to the workspace root
+	for _, dir := range []string{root, filepath.Join(root, "cmd")} {
+		tree, err := walker.Walk(context.Background(), dir, walker.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nestedJSON, err := formatDirectory(tree, formatJSON, false, treeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var nestedRoot dirEntry
+		if err := json.Unmarshal([]byte(nestedJSON), &nestedRoot); err != nil {
+			t.Fatal(err)
+		}
+		nested := entryPaths(&nestedRoot)
+
+		flatJSON, err := formatDirectory(tree, formatJSON, true, treeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var flatEntries []*dirEntry
+		if err := json.Unmarshal([]byte(flatJSON), &flatEntries); err != nil {
+			t.Fatal(err)
+		}
+		var flat []string
+		for _, entry := range flatEntries {
+			flat = append(flat, entry.Path)
+		}
+
+		ndjson, err := formatDirectory(tree, formatNDJSON, false, treeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(ndjson), "\n") {
+			var entry dirEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, entry.Path)
+		}
+
+		slices.Sort(nested)
+		slices.Sort(flat)
+		slices.Sort(lines)
+		if !slices.Equal(nested, flat) || !slices.Equal(flat, lines) {
+			t.Errorf("listing %s: nested paths %q, flat %q, ndjson %q, want them equal", dir, nested, flat, lines)
+		}
+		if !slices.Contains(nested, "cmd/sub/y.go") {
+			t.Errorf("listing %s: paths %q, want cmd/sub/y.go relative to the workspace root", dir, nested)
+		}
+	}
+}
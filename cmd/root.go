@@ -22,14 +22,17 @@ to quickly create a Cobra application.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: initConfig,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Exit codes distinguish success, validation failure and cancellation, see exit.go.
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		printExitError(err)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -38,7 +41,12 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.agent-code-assignment.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/agent-code/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&workspaceRoot, "root", "", "workspace root every command is confined to (default is $AGENT_CODE_ROOT, workspace.root in the config, git toplevel or current directory)")
+
+	// commands print their own errors with exit codes
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
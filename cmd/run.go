@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/components/runview"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/executor"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	runTimeout     time.Duration
+	runAutoApprove bool
+)
+
+// runCmd - run a terminal command inside the workspace
+var runCmd = &cobra.Command{
+	Use:   "run -- command [args...]",
+	Short: "Run a terminal command in the workspace root",
+	Long: `Run a terminal command in the workspace root with a scrubbed environment, a timeout and captured output.
+Commands on the allow list run straight away, commands on the deny list never run and anything else asks for confirmation.`,
+	Example: `  agent-code run -- go test ./...
+  agent-code run "git status -s"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().DurationVarP(&runTimeout, "timeout", "t", executor.DefaultTimeout, "kill the command after this long (default is run.timeout in the config)")
+	runCmd.Flags().BoolVarP(&runAutoApprove, "yes", "y", false, "run commands that are not pre-approved without asking")
+}
+
+func runCommand(cmd *cobra.Command, args []string) error {
+	argv, err := commandArgs(args)
+	if err != nil {
+		return validationError(err)
+	}
+
+	ex, err := newExecutor()
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("timeout") {
+		ex.Timeout = runTimeout
+	}
+
+	decision := ex.Decide(argv)
+	if decision == executor.Denied {
+		return validationError(ex.Policy.Check(argv))
+	}
+
+	needsApproval := decision == executor.NeedsApproval && !runAutoApprove
+	if needsApproval && (!canPrompt() || !canRender()) {
+		return validationError(fmt.Errorf("command %q is not pre-approved. pass --yes to run it without a terminal", strings.Join(argv, " ")))
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	// plain output when piped
+	if !canRender() {
+		result, err := ex.Run(ctx, argv, executor.RunOptions{Stdout: os.Stdout, Stderr: os.Stderr})
+		return commandStatus(result, err)
+	}
+
+	var approval chan bool
+	if needsApproval {
+		approval = make(chan bool, 1)
+	}
+
+	view := runview.InitialRunViewModel(strings.Join(argv, " "), approval, cancel)
+	tProgram := tea.NewProgram(view)
+
+	go func() {
+		if approval != nil {
+			select {
+			case approved := <-approval:
+				if !approved {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		result, err := ex.Run(ctx, argv, executor.RunOptions{
+			Stdout: &programWriter{program: tProgram},
+			Stderr: &programWriter{program: tProgram, stderr: true},
+		})
+		tProgram.Send(runview.DoneMsg{Result: result, Err: err})
+	}()
+
+	if _, err := tProgram.Run(); err != nil {
+		return err
+	}
+
+	if view.Cancelled() {
+		return cancelledError("Run operation cancelled.")
+	}
+
+	return commandStatus(view.Result())
+}
+
+// newExecutor - executor confined to the workspace root, with the configured policy and timeout
+func newExecutor() (*executor.Executor, error) {
+	ws, err := getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	ex := executor.New(ws.Root)
+	ex.Policy = cfg.Policy()
+	ex.Timeout = cfg.Run.Timeout
+	return ex, nil
+}
+
+// commandArgs - a single argument is split like a shell command line, several are used as is
+func commandArgs(args []string) ([]string, error) {
+	if len(args) == 1 {
+		argv, err := executor.SplitCommand(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(argv) == 0 {
+			return nil, fmt.Errorf("command cannot be empty")
+		}
+		return argv, nil
+	}
+
+	return args, nil
+}
+
+// commandStatus - error for a failed or non zero exit
+func commandStatus(result *executor.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if result != nil && result.ExitCode != 0 {
+		return fmt.Errorf("command exited with status %d", result.ExitCode)
+	}
+	return nil
+}
+
+// programWriter - forwards live command output to the bubbletea program
+type programWriter struct {
+	program *tea.Program
+	stderr  bool
+}
+
+func (w *programWriter) Write(p []byte) (int, error) {
+	w.program.Send(runview.OutputMsg{Text: string(p), Stderr: w.stderr})
+	return len(p), nil
+}
+
+// runCommandArgs - arguments of the run_command tool
+type runCommandArgs struct {
+	Command        string `json:"command"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// runCommandFromTool - run_command tool, the result is returned as JSON
+func runCommandFromTool(ctx context.Context, data json.RawMessage) (string, error) {
+	var args runCommandArgs
+	if err := json.Unmarshal(data, &args); err != nil {
+		return "", fmt.Errorf("invalid tool arguments: %w", err)
+	}
+
+	argv, err := commandArgs([]string{args.Command})
+	if err != nil {
+		return "", err
+	}
+
+	ex, err := newExecutor()
+	if err != nil {
+		return "", err
+	}
+	if args.TimeoutSeconds > 0 {
+		ex.Timeout = time.Duration(args.TimeoutSeconds) * time.Second
+	}
+
+	result, err := ex.Run(ctx, argv, executor.RunOptions{})
+	if err != nil && result == nil {
+		return "", err
+	}
+
+	out, marshalErr := json.MarshalIndent(result, "", "  ")
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+
+	return string(out), err
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/templates"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var (
+	templatesExt  string
+	templateCache *templates.Set
+)
+
+// templatesCmd - inspect the file templates
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Inspect the templates files are created from",
+	Long: `Files are created from text/template templates named <name><ext>.tmpl, e.g. http-handler.go.tmpl.
+Built in templates are overridden by the ones in ~/.config/agent-code/templates, which are overridden by the project's .agent-code/templates.
+Templates can use {{.Name}}, {{.File}}, {{.Dir}}, {{.Package}}, {{.Author}}, {{.Date}} and {{.Year}} plus the lower, upper, title and camel functions.`,
+}
+
+// templatesListCmd - list the templates per extension
+var templatesListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the available templates per extension",
+	Example: `  agent-code templates list --ext .go`,
+	Args:    cobra.NoArgs,
+	RunE:    listTemplates,
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+
+	templatesListCmd.Flags().StringVarP(&templatesExt, "ext", "e", "", "only list the templates of this extension")
+}
+
+// getTemplates - built in, user and project templates, loaded once per run
+func getTemplates() (*templates.Set, error) {
+	if templateCache != nil {
+		return templateCache, nil
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := templates.Load(templates.DefaultDirs(ws.Root)...)
+	if err != nil {
+		return nil, err
+	}
+
+	templateCache = set
+	return templateCache, nil
+}
+
+func listTemplates(cmd *cobra.Command, args []string) error {
+	set, err := getTemplates()
+	if err != nil {
+		return err
+	}
+
+	ext := templatesExt
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	current := ""
+	for _, tmpl := range set.List() {
+		if ext != "" && tmpl.Ext != ext {
+			continue
+		}
+
+		if tmpl.Ext != current {
+			current = tmpl.Ext
+			fmt.Println(ui.GetFileStyle(current).Render(current))
+		}
+
+		location := ""
+		if tmpl.Source != templates.SourceBuiltin {
+			location = tmpl.Path
+		}
+		fmt.Printf("  %s %s %s\n", ui.InfoStyle.Width(16).Render(tmpl.Name), ui.TextStyle.Width(8).Render(tmpl.Source), location)
+	}
+
+	if current == "" {
+		fmt.Println(ui.RenderInfo("no templates found"))
+	}
+
+	return nil
+}
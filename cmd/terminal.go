@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/mattn/go-isatty"
+	"os"
+)
+
+// canPrompt - interactive prompts are only shown when stdin is a terminal
+func canPrompt() bool {
+	return isTerminal(os.Stdin)
+}
+
+// canRender - styled TUI output is only rendered when stdout is a terminal
+func canRender() bool {
+	return isTerminal(os.Stdout)
+}
+
+func isTerminal(file *os.File) bool {
+	fd := file.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
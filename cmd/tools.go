@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/tools"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/walker"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+)
+
+// tool names
+const (
+	listDirectoryTool = "list_directory"
+	readFileTool      = "read_file"
+	createFileTool    = "create_file"
+	deletePathTool    = "delete_path"
+	editFileTool      = "edit_file"
+	applyPatchTool    = "apply_patch"
+	runCommandTool    = "run_command"
+)
+
+var (
+	toolsJSON    bool
+	toolRegistry *tools.Registry
+)
+
+// toolsCmd - inspect the registered tools
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Inspect the tools available to commands and the agent",
+}
+
+// toolsListCmd - list the registered tools
+var toolsListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the registered tools",
+	Long:    `List the registered tools, with --json their JSON Schema definitions are dumped so they can be fed to any model.`,
+	Example: `  agent-code tools list --json`,
+	Args:    cobra.NoArgs,
+	RunE:    listTools,
+}
+
+func init() {
+	rootCmd.AddCommand(toolsCmd)
+	toolsCmd.AddCommand(toolsListCmd)
+
+	toolsListCmd.Flags().BoolVar(&toolsJSON, "json", false, "print the tool definitions as JSON")
+}
+
+func listTools(cmd *cobra.Command, args []string) error {
+	registry := getToolRegistry()
+
+	if toolsJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(registry.Definitions())
+	}
+
+	for _, tool := range registry.List() {
+		kind := ui.SuccessStyle2.Render("read-only")
+		if tool.Mutating() {
+			kind = ui.ErrorStyle.UnsetMargins().Render("mutating ")
+		}
+		fmt.Printf("%s %s  %s\n", ui.InfoStyle.Width(16).Render(tool.Name()), kind, ui.TextStyle.Render(tool.Description()))
+	}
+
+	return nil
+}
+
+// getToolRegistry - registry of the file operations, shared by the commands and the agent
+func getToolRegistry() *tools.Registry {
+	if toolRegistry != nil {
+		return toolRegistry
+	}
+
+	cfg, err := getConfig()
+	cobra.CheckErr(err)
+
+	toolRegistry = tools.NewRegistry()
+	cobra.CheckErr(toolRegistry.Register(
+		tools.New(tools.Spec{
+			Name:        listDirectoryTool,
+			Description: "List a workspace directory recursively as a tree. Entries matched by .gitignore and .ignore files are left out.",
+			Schema: tools.Object(map[string]*tools.Schema{
+				"path":            tools.String("directory path relative to the workspace root, defaults to ."),
+				"show_hidden":     tools.Boolean("include hidden files and directories"),
+				"depth":           tools.Integer("levels of directories to descend, 0 for no limit"),
+				"include":         tools.Array("only list files matching these gitignore style globs, e.g. *.go", tools.String("glob")),
+				"exclude":         tools.Array("leave out files and directories matching these gitignore style globs", tools.String("glob")),
+				"no_ignore":       tools.Boolean("also list entries matched by .gitignore and .ignore files"),
+				"dirs_only":       tools.Boolean("list directories only"),
+				"format":          tools.Enum("output format, tree by default, json, ndjson and yaml include size, mode and modification time", readFormats...),
+				"flat":            tools.Boolean("list json and yaml entries flat instead of nested"),
+				"long":            tools.Boolean("show permissions, owner, size, modification time and git status of every entry"),
+				"sizes":           tools.Boolean("show file sizes and the total size and file count of directories, to spot large directories"),
+				"follow_symlinks": tools.Boolean("descend into symlinked directories inside the workspace"),
+			}),
+		}, listDirectory),
+		tools.New(tools.Spec{
+			Name:        readFileTool,
+			Description: fmt.Sprintf("Read a workspace file, lines are prefixed with their line number. Files over %s are cut short after the last whole line that fits.", filesystem.FormatSize(maxContextFileSize)),
+			Schema: tools.Object(map[string]*tools.Schema{
+				"path": tools.String("file path relative to the workspace root"),
+			}, "path"),
+		}, readFile),
+		tools.New(tools.Spec{
+			Name:        createFileTool,
+			Description: fmt.Sprintf("Create a new file from a language template, missing directories are created. Allowed extensions: %s.", strings.Join(cfg.Create.Extensions, ", ")),
+			Schema: tools.Object(map[string]*tools.Schema{
+				"path":     tools.String("new file path relative to the workspace root"),
+				"template": tools.String("template name, e.g. http-handler for .go files, defaults to default"),
+				"dry_run":  tools.Boolean("only report the directories and file that would be written"),
+			}, "path"),
+			Mutating: true,
+		}, createFileFromTemplate),
+		tools.New(tools.Spec{
+			Name:        deletePathTool,
+			Description: "Delete a workspace file, or a directory with all its contents, by moving it to the trash so it can be restored.",
+			Schema: tools.Object(map[string]*tools.Schema{
+				"path":      tools.String("file or directory path relative to the workspace root"),
+				"permanent": tools.Boolean("delete permanently instead of moving to the trash, only when explicitly asked for"),
+			}, "path"),
+			Mutating: true,
+		}, deletePath),
+		tools.New(tools.Spec{
+			Name:        editFileTool,
+			Description: "Change an existing text file, prefer edits for small changes. Returns the unified diff of the change, which can be undone.",
+			Schema: tools.Object(map[string]*tools.Schema{
+				"path":    tools.String("file path relative to the workspace root"),
+				"content": tools.String("full new content of the file"),
+				"patch":   tools.String("unified diff of the file, hunks may have moved"),
+				"edits": tools.Array("exact text replacements made in order", tools.Object(map[string]*tools.Schema{
+					"search":  tools.String("text to replace, must be in the file exactly once, include surrounding lines to make it unique"),
+					"replace": tools.String("replacement text"),
+				}, "search", "replace")),
+				"dry_run":     tools.Boolean("only return the diff without writing the file"),
+				"base_sha256": tools.String("sha256 hex digest of the content the change was made against, the edit is refused when the file no longer holds it"),
+			}, "path"),
+			Mutating: true,
+		}, editFileFromTool),
+		tools.New(tools.Spec{
+			Name:        applyPatchTool,
+			Description: "Apply a unified diff to the workspace, files may be created, deleted and renamed. Nothing is written unless every file applies.",
+			Schema: tools.Object(map[string]*tools.Schema{
+				"patch":   tools.String("unified diff as written by diff -u or git diff"),
+				"check":   tools.Boolean("only check that the patch applies without writing"),
+				"reverse": tools.Boolean("undo the patch instead of applying it"),
+				"strip":   tools.Integer("leading directories to remove from file names, by default the a/ and b/ of git diffs"),
+				"fuzz":    tools.Integer("context lines at either end of a hunk that may differ, 2 by default"),
+			}, "patch"),
+			Mutating: true,
+		}, applyPatchFromTool),
+		tools.New(tools.Spec{
+			Name:        runCommandTool,
+			Description: "Run a terminal command in the workspace root without a shell. Returns stdout, stderr and the exit code as JSON.",
+			Schema: tools.Object(map[string]*tools.Schema{
+				"command":         tools.String("command line, quoted like a shell command but pipes and redirects are not supported"),
+				"timeout_seconds": tools.Integer("kill the command after this many seconds"),
+			}, "command"),
+			Mutating: true,
+		}, runCommandFromTool),
+	))
+
+	return toolRegistry
+}
+
+// pathArgs - arguments of the tools working on a single path
+type pathArgs struct {
+	Path       string   `json:"path"`
+	ShowHidden bool     `json:"show_hidden,omitempty"`
+	Permanent  bool     `json:"permanent,omitempty"`
+	Template   string   `json:"template,omitempty"`
+	Depth      int      `json:"depth,omitempty"`
+	Include    []string `json:"include,omitempty"`
+	Exclude    []string `json:"exclude,omitempty"`
+	NoIgnore   bool     `json:"no_ignore,omitempty"`
+	DirsOnly   bool     `json:"dirs_only,omitempty"`
+	Format     string   `json:"format,omitempty"`
+	Flat       bool     `json:"flat,omitempty"`
+	Long       bool     `json:"long,omitempty"`
+	Sizes      bool     `json:"sizes,omitempty"`
+	Follow     bool     `json:"follow_symlinks,omitempty"`
+	DryRun     bool     `json:"dry_run,omitempty"`
+}
+
+type styledOutputKey struct{}
+
+// withStyledOutput - ask tools to render lipgloss styled output, used when a command prints the result itself
+func withStyledOutput(ctx context.Context) context.Context {
+	return context.WithValue(ctx, styledOutputKey{}, true)
+}
+
+func styledOutput(ctx context.Context) bool {
+	styled, _ := ctx.Value(styledOutputKey{}).(bool)
+	return styled
+}
+
+type walkProgressKey struct{}
+
+// withWalkProgress - report how far directory walks of tools got, see walker.Options.Progress
+func withWalkProgress(ctx context.Context, progress func(done, found int)) context.Context {
+	return context.WithValue(ctx, walkProgressKey{}, progress)
+}
+
+func walkProgress(ctx context.Context) func(done, found int) {
+	progress, _ := ctx.Value(walkProgressKey{}).(func(done, found int))
+	return progress
+}
+
+func decodePathArgs(data json.RawMessage) (pathArgs, error) {
+	var args pathArgs
+	if err := json.Unmarshal(data, &args); err != nil {
+		return args, fmt.Errorf("invalid tool arguments: %w", err)
+	}
+
+	return args, nil
+}
+
+func listDirectory(ctx context.Context, data json.RawMessage) (string, error) {
+	args, err := decodePathArgs(data)
+	if err != nil {
+		return "", err
+	}
+	if args.Path == "" {
+		args.Path = "."
+	}
+
+	path, err := resolvePath(args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("path %s does not exist", args.Path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error accessing path %s: %w", args.Path, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("path %s is an invalid directory", args.Path)
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return "", err
+	}
+
+	tree, err := walker.Walk(ctx, path, walker.Options{
+		Depth:      args.Depth,
+		Include:    args.Include,
+		Exclude:    args.Exclude,
+		Hidden:     args.ShowHidden,
+		DirsOnly:   args.DirsOnly,
+		Ignore:     !args.NoIgnore,
+		IgnoreRoot: ws.Root,
+		Totals:     args.Sizes,
+
+		FollowSymlinks: args.Follow,
+		Boundary:       ws.Root,
+		Progress:       walkProgress(ctx),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error getting dir contents %v: %w", path, err)
+	}
+	if tree.Err != nil {
+		return "", fmt.Errorf("error getting dir contents %v: %w", path, tree.Err)
+	}
+
+	opts := treeOptions{styled: styledOutput(ctx), long: args.Long, sizes: args.Sizes}
+	if args.Long {
+		opts.git = walker.GitStatus(path)
+		opts.ownerWidth = ownerWidth(tree)
+	}
+
+	if args.Format != "" && args.Format != formatTree {
+		return formatDirectory(tree, args.Format, args.Flat, opts)
+	}
+
+	var buf bytes.Buffer
+	printDirectory(&buf, tree, "", opts)
+	if args.Long || args.Sizes {
+		_, _ = fmt.Fprintf(&buf, "\n%s\n", treeSummary(tree, opts))
+	}
+
+	return buf.String(), nil
+}
+
+func readFile(ctx context.Context, data json.RawMessage) (string, error) {
+	args, err := decodePathArgs(data)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := validateSearchFile(args.Path); err != nil {
+		return "", err
+	}
+
+	path, err := resolvePath(args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := filesystem.Sniff(path)
+	if err != nil {
+		return "", err
+	}
+	if content.Size <= maxContextFileSize || content.Binary() {
+		return displayFileContents(path)
+	}
+
+	return readFileHead(path, content)
+}
+
+// readFileHead - the numbered lines of the first maxContextFileSize bytes of a text file, so a
+// large file cannot fill the model's context, with a note saying where it was cut
+func readFileHead(path string, content filesystem.Content) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	head := make([]byte, maxContextFileSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+
+	// the last line is cut off unless the head happens to end with one
+	text := filesystem.DecodeText(head[:n], content.Encoding)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		text = text[:i+1]
+	}
+
+	lines := textLines(text)
+	return fmt.Sprintf("%s\n[truncated: the file is %s, only lines 1-%d (the first %s) are shown]",
+		numberLines(lines), filesystem.FormatSize(content.Size), len(lines), filesystem.FormatSize(maxContextFileSize)), nil
+}
+
+func createFileFromTemplate(ctx context.Context, data json.RawMessage) (string, error) {
+	args, err := decodePathArgs(data)
+	if err != nil {
+		return "", err
+	}
+
+	plan, err := createNewFile(args.Path, args.Template, args.DryRun)
+	if err != nil {
+		return "", err
+	}
+
+	return describeCreate(args.Path, plan, args.DryRun)
+}
+
+func deletePath(ctx context.Context, data json.RawMessage) (string, error) {
+	args, err := decodePathArgs(data)
+	if err != nil {
+		return "", err
+	}
+
+	absPath, isDir, err := validateDeleteFile(args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	j, err := getJournal()
+	if err != nil {
+		return "", err
+	}
+
+	if args.Permanent {
+		if _, err := filesystem.Remove(absPath); err != nil {
+			return "", err
+		}
+		if _, err := j.RecordDelete(absPath, isDir, nil, nil); err != nil {
+			return "", fmt.Errorf("%s deleted but not recorded in the journal: %w", absPath, err)
+		}
+
+		return fmt.Sprintf("Successfully deleted %s: %s", filesystem.ItemType(isDir), absPath), nil
+	}
+
+	bin, err := getTrash()
+	if err != nil {
+		return "", err
+	}
+
+	item, err := bin.Put(absPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := j.RecordDelete(absPath, isDir, bin, item); err != nil {
+		return "", fmt.Errorf("%s moved to trash but not recorded in the journal: %w", absPath, err)
+	}
+
+	return fmt.Sprintf("Moved %s to trash: %s (restore with `agent-code trash restore %s`)", filesystem.ItemType(isDir), absPath, item.Name), nil
+}
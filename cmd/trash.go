@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/trash"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+var (
+	trashJSON    bool
+	confirmEmpty bool
+)
+
+// trashCmd - manage deleted files
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or empty deleted files",
+	Long: `Deleted files and directories are moved to the trash instead of being removed.
+The trash lives in the workspace under .agent-code/trash, set trash.location to home in the config (or AGENT_CODE_TRASH=home) to use ~/.local/share/Trash instead.
+Only the items deleted from the current workspace are listed, restored and emptied, the home trash keeps those of other projects.`,
+}
+
+// trashListCmd - list trashed items
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trashed files, most recently deleted first",
+	Args:  cobra.NoArgs,
+	RunE:  listTrash,
+}
+
+// trashRestoreCmd - restore trashed items
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore name|path...",
+	Short: "Restore trashed files to their original path",
+	Long: `Restore trashed files to their original path, missing parent directories are recreated.
+An item is picked by its trash name as shown by 'trash list', or by its original path in which case the most recent deletion is restored.`,
+	Example: `  agent-code trash restore main.go
+  agent-code trash restore build.2`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: restoreTrash,
+}
+
+// trashEmptyCmd - permanently delete all trashed items
+var trashEmptyCmd = &cobra.Command{
+	Use:     "empty",
+	Short:   "Permanently delete everything in the trash",
+	Example: `  agent-code trash empty --yes`,
+	Args:    cobra.NoArgs,
+	RunE:    emptyTrash,
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+
+	trashListCmd.Flags().BoolVar(&trashJSON, "json", false, "print the trashed items as JSON")
+	trashEmptyCmd.Flags().BoolVarP(&confirmEmpty, "yes", "y", false, "confirm that the trash should be emptied")
+}
+
+// getTrash - trash deletes are moved to
+func getTrash() (*trash.Trash, error) {
+	ws, err := getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return trash.Open(ws.Root, cfg.Trash.Location)
+}
+
+func listTrash(cmd *cobra.Command, args []string) error {
+	bin, err := getTrash()
+	if err != nil {
+		return err
+	}
+
+	items, err := bin.List()
+	if err != nil {
+		return err
+	}
+
+	if trashJSON {
+		if items == nil {
+			items = []trash.Item{}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(items)
+	}
+
+	if len(items) == 0 {
+		fmt.Println(ui.RenderInfo("trash is empty"))
+		return nil
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		fmt.Printf("%s  %s  %-9s  %s\n",
+			ui.InfoStyle.Width(24).Render(item.Name),
+			item.DeletedAt.Format(time.DateTime),
+			filesystem.ItemType(item.IsDir),
+			ui.TextStyle.Render(ws.Rel(item.OriginalPath)),
+		)
+	}
+
+	return nil
+}
+
+func restoreTrash(cmd *cobra.Command, args []string) error {
+	bin, err := getTrash()
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		item, err := findTrashItem(bin, arg)
+		if err != nil {
+			return validationError(err)
+		}
+
+		if _, err := bin.Restore(item.Name); err != nil {
+			if errors.Is(err, trash.ErrRestoreConflict) || errors.Is(err, trash.ErrOutsideRoot) {
+				return validationError(err)
+			}
+			return err
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Restored %s: %s", filesystem.ItemType(item.IsDir), item.OriginalPath)))
+	}
+
+	return nil
+}
+
+// findTrashItem - look up by trash name first, then by the original path inside the workspace
+func findTrashItem(bin *trash.Trash, arg string) (*trash.Item, error) {
+	item, err := bin.Find(arg)
+	if err == nil || !errors.Is(err, trash.ErrNotFound) {
+		return item, err
+	}
+
+	ws, wsErr := getWorkspace()
+	if wsErr != nil {
+		return nil, err
+	}
+
+	path, resolveErr := ws.ResolveEntry(arg)
+	if resolveErr != nil {
+		return nil, err
+	}
+
+	return bin.Find(path)
+}
+
+func emptyTrash(cmd *cobra.Command, args []string) error {
+	bin, err := getTrash()
+	if err != nil {
+		return err
+	}
+
+	items, err := bin.List()
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Println(ui.RenderInfo("trash is already empty"))
+		return nil
+	}
+
+	if !confirmEmpty {
+		return validationError(fmt.Errorf("refusing to permanently delete %d trashed item(s) without confirmation. pass --yes to empty the trash", len(items)))
+	}
+
+	count, err := bin.Empty()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.RenderSuccess(fmt.Sprintf("Permanently deleted %d item(s) from the trash", count)))
+	return nil
+}
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -15,21 +17,21 @@ func validateSearchFile(fileName string) (bool, error) {
 		return false, fmt.Errorf("filename cannot be empty")
 	}
 
-	//check if the file exists
-	if _, err := os.Stat(fileName); os.IsNotExist(err) {
-		return false, fmt.Errorf("file %s does not exist. incorrect path or file name \n", fileName)
+	// resolve the path inside the workspace
+	path, err := resolvePath(fileName)
+	if err != nil {
+		return false, err
 	}
 
-	// get the file path
-	path, err := filepath.Abs(fileName)
-	if err != nil {
-		return false, fmt.Errorf("error getting absolute path %s\n", path)
+	//check if the file exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, fmt.Errorf("file %s does not exist. incorrect path or file name \n", fileName)
 	}
 
 	return true, nil
 }
 
-// validation
+// validateFileCreate - check a new file name without touching the disk, see createNewFile for the write
 func validateFileCreate(fileName string, allowedExtensions []string) (bool, error) {
 	// check filename if is empty
 	if strings.TrimSpace(fileName) == "" {
@@ -43,70 +45,66 @@ func validateFileCreate(fileName string, allowedExtensions []string) (bool, erro
 		return false, fmt.Errorf("invalid file extension. allowed: %s", strings.Join(allowedExtensions, ", "))
 	}
 
-	// check if the file already exists
-	if _, err := os.Stat(fileName); !os.IsNotExist(err) {
-		return false, fmt.Errorf("file '%s' already exists", fileName)
-	}
-
-	// generate directory if included in the file path
-	if err := generateFileDirectory(fileName); err != nil {
-		return false, fmt.Errorf("error creating directory: %v", err)
-	}
-
-	// create the file
-	file, err := os.Create(fileName)
+	// resolve the path inside the workspace
+	path, err := resolveWritable(fileName)
 	if err != nil {
-		return false, fmt.Errorf("error creating file: %v", err)
-	}
-
-	// generate file template and write to file
-	temp := generateFileTemplate(fileName)
-	if temp != "" {
-		if _, err := file.WriteString(temp); err != nil {
-			err := file.Close()
-			if err != nil {
-				return false, fmt.Errorf("error closing file: %v", err)
-			}
-			return false, fmt.Errorf("error writing to file: %v", err)
-		}
+		return false, err
 	}
 
-	// close file
-	if err := file.Close(); err != nil {
-		return false, fmt.Errorf("error closing file: %v", err)
+	// the file must not exist yet and its parents must be directories or missing
+	if _, err := filesystem.PlanCreate(path, 0); errors.Is(err, filesystem.ErrExists) {
+		return false, fmt.Errorf("file '%s' already exists", fileName)
+	} else if err != nil {
+		return false, err
 	}
 
 	return true, nil
 }
 
 // validateOpenFile - validate open file
-func validateOpenFile(fileName, editor string) (string, bool, error) {
-	var cmd *exec.Cmd
-
+func validateOpenFile(fileName, editorName string) (string, bool, error) {
 	if strings.TrimSpace(fileName) == "" {
 		return "", false, fmt.Errorf("filename cannot be empty")
 	}
 
-	if strings.TrimSpace(editor) == "" {
+	if strings.TrimSpace(editorName) == "" {
 		return "", false, fmt.Errorf("error reading open file %s\n", fileName)
 	}
 
-	// get the file path
-	path, err := filepath.Abs(fileName)
+	// resolve the path inside the workspace
+	path, err := resolvePath(fileName)
 	if err != nil {
-		return "", false, fmt.Errorf("error getting absolute path %s\n", path)
+		return "", false, err
 	}
 
-	switch strings.ToLower(editor) {
-	case "code":
-		cmd = exec.Command("code", path)
-		return "", true, cmd.Start()
-	default:
+	registry, err := getEditors()
+	if err != nil {
+		return "", false, err
+	}
+
+	ed, err := registry.Find(editorName)
+	if err != nil {
+		return "", false, err
+	}
+
+	if ed.Viewer {
 		// display file data
-		code, err := displayFileContents(fileName)
+		code, err := getToolRegistry().Call(context.Background(), readFileTool, pathArgs{Path: fileName})
 		if err != nil {
 			return "", false, fmt.Errorf("error opening file %s - %v\n", path, err)
 		}
 		return code, true, nil
 	}
+
+	cmd, err := ed.Cmd(path, openLine, openColumn)
+	if err != nil {
+		return "", false, err
+	}
+
+	// terminal editors take over the terminal until they exit, others are only started
+	if ed.Terminal {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return "", true, cmd.Run()
+	}
+	return "", true, cmd.Start()
 }
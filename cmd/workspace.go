@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/journal"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/trash"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+)
+
+var (
+	workspaceRoot string
+	currentWs     *workspace.Workspace
+)
+
+// getWorkspace - workspace every file command is confined to, detected once per run
+// while loading the config
+func getWorkspace() (*workspace.Workspace, error) {
+	if currentWs != nil {
+		return currentWs, nil
+	}
+
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := workspace.New(cfg.Workspace.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	currentWs = ws
+	return currentWs, nil
+}
+
+// resolvePath - resolve a user supplied path inside the workspace
+func resolvePath(path string) (string, error) {
+	ws, err := getWorkspace()
+	if err != nil {
+		return "", err
+	}
+
+	return ws.Resolve(path)
+}
+
+// resolveWritable - like resolvePath, but the journal and the workspace trash are refused. a file
+// written there could pose as an undo entry or a trashed item and undo would act on it
+func resolveWritable(path string) (string, error) {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	ws, err := getWorkspace()
+	if err != nil {
+		return "", err
+	}
+	if journal.ForWorkspace(ws.Root, "").Contains(resolved) || trash.ForWorkspace(ws.Root).Contains(resolved) {
+		return "", fmt.Errorf("%s is kept by agent-code and cannot be written", path)
+	}
+
+	return resolved, nil
+}
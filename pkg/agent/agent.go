@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/tools"
+)
+
+// DefaultMaxSteps - model round trips allowed before the loop gives up
+const DefaultMaxSteps = 10
+
+// ErrStepLimit - the model kept calling tools past the step limit
+var ErrStepLimit = errors.New("agent reached the step limit without a final answer")
+
+// Event - progress reported while the loop runs
+type Event interface {
+	event()
+}
+
+// TextEvent - streamed assistant text
+type TextEvent struct {
+	Text string
+}
+
+// ToolCallEvent - the model requested a tool call
+type ToolCallEvent struct {
+	Call     provider.ToolCall
+	Mutating bool
+}
+
+// ToolResultEvent - outcome of a tool call fed back to the model
+type ToolResultEvent struct {
+	Call   provider.ToolCall
+	Result string
+	Err    error
+	Denied bool
+}
+
+// StepEvent - a model round trip finished
+type StepEvent struct {
+	Step  int
+	Usage provider.Usage
+}
+
+func (TextEvent) event()       {}
+func (ToolCallEvent) event()   {}
+func (ToolResultEvent) event() {}
+func (StepEvent) event()       {}
+
+// Agent - multi-turn loop letting the model call tools until it answers
+type Agent struct {
+	Provider     provider.Provider
+	Tools        *tools.Registry
+	SystemPrompt string
+	MaxSteps     int
+
+	// Approve - asked before every mutating tool call, nil denies them all
+	Approve func(ctx context.Context, call provider.ToolCall) (bool, error)
+
+	// OnEvent - receives progress events, may be nil
+	OnEvent func(Event)
+}
+
+// Run - send the prompt and loop over tool calls until the model gives a final answer
+func (a *Agent) Run(ctx context.Context, prompt string) (string, error) {
+	maxSteps := a.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	var definitions []provider.Tool
+	if a.Tools != nil {
+		for _, tool := range a.Tools.List() {
+			parameters, err := json.Marshal(tool.Schema())
+			if err != nil {
+				return "", fmt.Errorf("error encoding %s schema: %w", tool.Name(), err)
+			}
+
+			definitions = append(definitions, provider.Tool{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  parameters,
+			})
+		}
+	}
+
+	var messages []provider.Message
+	if a.SystemPrompt != "" {
+		messages = append(messages, provider.Message{Role: provider.RoleSystem, Content: a.SystemPrompt})
+	}
+	messages = append(messages, provider.Message{Role: provider.RoleUser, Content: prompt})
+
+	for step := 1; step <= maxSteps; step++ {
+		stream, err := a.Provider.Stream(ctx, provider.Request{Messages: messages, Tools: definitions})
+		if err != nil {
+			return "", err
+		}
+
+		resp, err := provider.Collect(stream, func(chunk provider.Chunk) {
+			if chunk.Content != "" {
+				a.emit(TextEvent{Text: chunk.Content})
+			}
+		})
+		if err != nil {
+			return "", err
+		}
+
+		a.emit(StepEvent{Step: step, Usage: resp.Usage})
+		messages = append(messages, resp.Message)
+
+		// no tool calls, this is the final answer
+		if len(resp.Message.ToolCalls) == 0 {
+			return resp.Message.Content, nil
+		}
+
+		for _, call := range resp.Message.ToolCalls {
+			result, err := a.call(ctx, call)
+			if err != nil {
+				return "", err
+			}
+
+			messages = append(messages, provider.Message{
+				Role:       provider.RoleTool,
+				ToolCallID: call.ID,
+				Name:       call.Name,
+				Content:    result,
+			})
+		}
+	}
+
+	return "", ErrStepLimit
+}
+
+// call - run a single tool call, tool failures are reported to the model rather than aborting
+func (a *Agent) call(ctx context.Context, call provider.ToolCall) (string, error) {
+	var tool tools.Tool
+	ok := false
+	if a.Tools != nil {
+		tool, ok = a.Tools.Get(call.Name)
+	}
+	if !ok {
+		err := fmt.Errorf("%w %q", tools.ErrUnknownTool, call.Name)
+		a.emit(ToolResultEvent{Call: call, Err: err})
+		return "error: " + err.Error(), nil
+	}
+
+	a.emit(ToolCallEvent{Call: call, Mutating: tool.Mutating()})
+
+	if tool.Mutating() {
+		approved := false
+		if a.Approve != nil {
+			var err error
+			approved, err = a.Approve(ctx, call)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if !approved {
+			a.emit(ToolResultEvent{Call: call, Denied: true})
+			return "error: the user denied this tool call", nil
+		}
+	}
+
+	result, err := a.Tools.Execute(ctx, call.Name, json.RawMessage(call.Arguments))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+
+	a.emit(ToolResultEvent{Call: call, Result: result, Err: err})
+	if err != nil {
+		return "error: " + err.Error(), nil
+	}
+
+	return result, nil
+}
+
+func (a *Agent) emit(event Event) {
+	if a.OnEvent != nil {
+		a.OnEvent(event)
+	}
+}
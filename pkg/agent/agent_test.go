@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/tools"
+	"strings"
+	"testing"
+)
+
+// testRegistry - a read only echo tool and a mutating write tool that records what it wrote
+func testRegistry(t *testing.T, written *[]string) *tools.Registry {
+	t.Helper()
+
+	registry := tools.NewRegistry()
+	err := registry.Register(
+		tools.New(tools.Spec{
+			Name:   "echo",
+			Schema: tools.Object(map[string]*tools.Schema{"text": tools.String("text to echo")}, "text"),
+		}, func(ctx context.Context, data json.RawMessage) (string, error) {
+			var args struct{ Text string }
+			if err := json.Unmarshal(data, &args); err != nil {
+				return "", err
+			}
+			if args.Text == "fail" {
+				return "", fmt.Errorf("echo failed")
+			}
+			return "echo: " + args.Text, nil
+		}),
+		tools.New(tools.Spec{
+			Name:     "write",
+			Schema:   tools.Object(map[string]*tools.Schema{"path": tools.String("file to write")}, "path"),
+			Mutating: true,
+		}, func(ctx context.Context, data json.RawMessage) (string, error) {
+			var args struct{ Path string }
+			if err := json.Unmarshal(data, &args); err != nil {
+				return "", err
+			}
+			*written = append(*written, args.Path)
+			return "wrote " + args.Path, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func toolCall(id, name, arguments string) provider.Response {
+	return provider.Response{Message: provider.Message{ToolCalls: []provider.ToolCall{{ID: id, Name: name, Arguments: arguments}}}}
+}
+
+func answer(content string) provider.Response {
+	return provider.Response{Message: provider.Message{Content: content}}
+}
+
+// toolMessages - the tool results sent back to the model in a request
+func toolMessages(req provider.Request) []provider.Message {
+	var messages []provider.Message
+	for _, msg := range req.Messages {
+		if msg.Role == provider.RoleTool {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func TestRunToolRoundTrip(t *testing.T) {
+	var written []string
+	fake := provider.NewFake(
+		toolCall("call_1", "echo", `{"text":"hello"}`),
+		answer("all done"),
+	)
+
+	var events []Event
+	a := &Agent{
+		Provider:     fake,
+		Tools:        testRegistry(t, &written),
+		SystemPrompt: "be brief",
+		OnEvent:      func(event Event) { events = append(events, event) },
+	}
+
+	got, err := a.Run(context.Background(), "say hello")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got != "all done" {
+		t.Errorf("Run() = %q, want %q", got, "all done")
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want 2", len(requests))
+	}
+	if first := requests[0].Messages; len(first) != 2 || first[0].Role != provider.RoleSystem || first[1].Content != "say hello" {
+		t.Errorf("first request messages = %+v, want the system prompt and the prompt", first)
+	}
+	if len(requests[0].Tools) != 2 {
+		t.Errorf("first request offered %d tools, want 2", len(requests[0].Tools))
+	}
+
+	results := toolMessages(requests[1])
+	if len(results) != 1 || results[0].ToolCallID != "call_1" || results[0].Name != "echo" || results[0].Content != "echo: hello" {
+		t.Errorf("tool results sent back = %+v, want the echo of call_1", results)
+	}
+
+	var steps, calls int
+	for _, event := range events {
+		switch event.(type) {
+		case StepEvent:
+			steps++
+		case ToolCallEvent:
+			calls++
+		}
+	}
+	if steps != 2 || calls != 1 {
+		t.Errorf("%d step and %d tool call events, want 2 and 1", steps, calls)
+	}
+}
+
+func TestRunToolErrorsGoBackToTheModel(t *testing.T) {
+	tests := []struct {
+		name string
+		call provider.Response
+		want string
+	}{
+		{"tool failure", toolCall("c", "echo", `{"text":"fail"}`), "error: echo failed"},
+		{"unknown tool", toolCall("c", "missing", `{}`), "error: unknown tool"},
+		{"invalid arguments", toolCall("c", "echo", `{}`), "error: invalid tool arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written []string
+			fake := provider.NewFake(tt.call, answer("sorry"))
+			a := &Agent{Provider: fake, Tools: testRegistry(t, &written)}
+
+			if _, err := a.Run(context.Background(), "go"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			results := toolMessages(fake.Requests()[1])
+			if len(results) != 1 || !strings.HasPrefix(results[0].Content, tt.want) {
+				t.Errorf("tool results sent back = %+v, want one starting with %q", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		approve func(ctx context.Context, call provider.ToolCall) (bool, error)
+		wrote   bool
+		wantErr bool
+	}{
+		{"approved", func(ctx context.Context, call provider.ToolCall) (bool, error) { return true, nil }, true, false},
+		{"denied", func(ctx context.Context, call provider.ToolCall) (bool, error) { return false, nil }, false, false},
+		{"no approver", nil, false, false},
+		{"approver fails", func(ctx context.Context, call provider.ToolCall) (bool, error) { return false, errors.New("no tty") }, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written []string
+			fake := provider.NewFake(toolCall("c", "write", `{"path":"main.go"}`), answer("finished"))
+
+			var denied bool
+			a := &Agent{
+				Provider: fake,
+				Tools:    testRegistry(t, &written),
+				Approve:  tt.approve,
+				OnEvent: func(event Event) {
+					if result, ok := event.(ToolResultEvent); ok && result.Denied {
+						denied = true
+					}
+				},
+			}
+
+			_, err := a.Run(context.Background(), "write it")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, want error %v", err, tt.wantErr)
+			}
+			if wrote := len(written) > 0; wrote != tt.wrote {
+				t.Errorf("tool ran = %v, want %v", wrote, tt.wrote)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			results := toolMessages(fake.Requests()[1])
+			if len(results) != 1 {
+				t.Fatalf("%d tool results sent back, want 1", len(results))
+			}
+			if !tt.wrote && (!denied || results[0].Content != "error: the user denied this tool call") {
+				t.Errorf("denied call reported as %q, denied event %v", results[0].Content, denied)
+			}
+		})
+	}
+}
+
+func TestRunStepLimit(t *testing.T) {
+	var written []string
+	fake := provider.NewFake()
+	for i := 0; i < 5; i++ {
+		fake.Push(toolCall(fmt.Sprintf("c%d", i), "echo", `{"text":"again"}`))
+	}
+
+	a := &Agent{Provider: fake, Tools: testRegistry(t, &written), MaxSteps: 3}
+	if _, err := a.Run(context.Background(), "loop"); !errors.Is(err, ErrStepLimit) {
+		t.Fatalf("Run() error = %v, want %v", err, ErrStepLimit)
+	}
+	if n := len(fake.Requests()); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := &Agent{Provider: provider.NewFake(answer("never"))}
+	if _, err := a.Run(ctx, "hi"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
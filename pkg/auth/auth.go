@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/user"
+)
+
+var (
+	// ErrInvalidCredentials - the password is wrong
+	ErrInvalidCredentials = errors.New("invalid password")
+
+	// ErrUnavailable - the verifier cannot check passwords on this system, the next one is tried
+	ErrUnavailable = errors.New("password verification is not available")
+
+	// ErrEmptyPassword - no password was given
+	ErrEmptyPassword = errors.New("password cannot be empty")
+)
+
+// Verifier - checks a user's system password
+type Verifier interface {
+	Verify(ctx context.Context, username, password string) error
+}
+
+// VerifierFunc - function adapter for Verifier, handy for injecting a fake in tests
+type VerifierFunc func(ctx context.Context, username, password string) error
+
+// Verify implements Verifier
+func (f VerifierFunc) Verify(ctx context.Context, username, password string) error {
+	return f(ctx, username, password)
+}
+
+// Chain - tries verifiers in order, moving on while they report ErrUnavailable
+type Chain []Verifier
+
+// Verify implements Verifier
+func (c Chain) Verify(ctx context.Context, username, password string) error {
+	if password == "" {
+		return ErrEmptyPassword
+	}
+
+	for _, verifier := range c {
+		err := verifier.Verify(ctx, username, password)
+		if errors.Is(err, ErrUnavailable) {
+			continue
+		}
+		return err
+	}
+
+	return fmt.Errorf("%w: no supported method on this system", ErrUnavailable)
+}
+
+// System - verifiers for the current platform: PAM when built with the pam tag,
+// /etc/shadow when readable (running privileged) and `sudo -k true` as the fallback
+func System() Verifier {
+	var chain Chain
+	if pam := pamVerifier(); pam != nil {
+		chain = append(chain, pam)
+	}
+
+	return append(chain, &Shadow{Path: DefaultShadowPath}, &Sudo{})
+}
+
+// CurrentUsername - login name of the user running the process
+func CurrentUsername() (string, error) {
+	current, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("unable to get current user: %w", err)
+	}
+	return current.Username, nil
+}
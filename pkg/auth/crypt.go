@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedHash - the crypt(3) hash scheme is not implemented (e.g. yescrypt, bcrypt)
+var ErrUnsupportedHash = errors.New("unsupported password hash scheme")
+
+const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const (
+	shaCryptDefaultRounds = 5000
+	shaCryptMinRounds     = 1000
+	shaCryptMaxRounds     = 999999999
+	shaCryptMaxSalt       = 16
+	md5CryptMaxSalt       = 8
+	md5CryptRounds        = 1000
+)
+
+// byte order of the SHA-crypt and MD5-crypt encodings, three bytes per group
+var (
+	sha512Order = [][3]int{
+		{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48},
+		{28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13},
+		{56, 14, 35}, {15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41},
+	}
+	sha256Order = [][3]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29},
+	}
+	md5Order = [][3]int{
+		{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5},
+	}
+)
+
+// CompareHash - check a password against a crypt(3) hash, supports SHA-512 ($6$), SHA-256 ($5$) and MD5 ($1$)
+func CompareHash(hashed, password string) error {
+	computed, err := cryptHash(hashed, password)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(computed), []byte(hashed)) != 1 {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+func cryptHash(hashed, password string) (string, error) {
+	switch {
+	case strings.HasPrefix(hashed, "$6$"):
+		return shaCrypt(sha512.New, "$6$", sha512Order, hashed, password)
+	case strings.HasPrefix(hashed, "$5$"):
+		return shaCrypt(sha256.New, "$5$", sha256Order, hashed, password)
+	case strings.HasPrefix(hashed, "$1$"):
+		return md5Crypt(hashed, password)
+	default:
+		return "", ErrUnsupportedHash
+	}
+}
+
+// shaCrypt - Ulrich Drepper's SHA-crypt
+func shaCrypt(newHash func() hash.Hash, magic string, order [][3]int, setting, password string) (string, error) {
+	rest := strings.TrimPrefix(setting, magic)
+
+	rounds := shaCryptDefaultRounds
+	customRounds := false
+	if strings.HasPrefix(rest, "rounds=") {
+		end := strings.IndexByte(rest, '$')
+		if end < 0 {
+			return "", ErrUnsupportedHash
+		}
+
+		n, err := strconv.Atoi(rest[len("rounds="):end])
+		if err != nil {
+			return "", ErrUnsupportedHash
+		}
+
+		rounds = min(max(n, shaCryptMinRounds), shaCryptMaxRounds)
+		customRounds = true
+		rest = rest[end+1:]
+	}
+
+	salt := rest
+	if end := strings.IndexByte(salt, '$'); end >= 0 {
+		salt = salt[:end]
+	}
+	if len(salt) > shaCryptMaxSalt {
+		salt = salt[:shaCryptMaxSalt]
+	}
+
+	p := []byte(password)
+	s := []byte(salt)
+
+	// digest B
+	h := newHash()
+	h.Write(p)
+	h.Write(s)
+	h.Write(p)
+	b := h.Sum(nil)
+	size := len(b)
+
+	// digest A
+	h = newHash()
+	h.Write(p)
+	h.Write(s)
+	n := len(p)
+	for ; n > size; n -= size {
+		h.Write(b)
+	}
+	h.Write(b[:n])
+	for n = len(p); n > 0; n >>= 1 {
+		if n&1 != 0 {
+			h.Write(b)
+		} else {
+			h.Write(p)
+		}
+	}
+	a := h.Sum(nil)
+
+	// byte sequence P
+	h = newHash()
+	for i := 0; i < len(p); i++ {
+		h.Write(p)
+	}
+	pSeq := repeatTo(h.Sum(nil), len(p))
+
+	// byte sequence S
+	h = newHash()
+	for i := 0; i < 16+int(a[0]); i++ {
+		h.Write(s)
+	}
+	sSeq := repeatTo(h.Sum(nil), len(s))
+
+	c := a
+	for i := 0; i < rounds; i++ {
+		h = newHash()
+		if i&1 != 0 {
+			h.Write(pSeq)
+		} else {
+			h.Write(c)
+		}
+		if i%3 != 0 {
+			h.Write(sSeq)
+		}
+		if i%7 != 0 {
+			h.Write(pSeq)
+		}
+		if i&1 != 0 {
+			h.Write(c)
+		} else {
+			h.Write(pSeq)
+		}
+		c = h.Sum(nil)
+	}
+
+	var out strings.Builder
+	out.WriteString(magic)
+	if customRounds {
+		out.WriteString("rounds=" + strconv.Itoa(rounds) + "$")
+	}
+	out.WriteString(salt + "$")
+
+	for _, group := range order {
+		encode24(&out, c[group[0]], c[group[1]], c[group[2]], 4)
+	}
+	if size == sha512.Size {
+		encode24(&out, 0, 0, c[63], 2)
+	} else {
+		encode24(&out, 0, c[31], c[30], 3)
+	}
+
+	return out.String(), nil
+}
+
+// md5Crypt - Poul-Henning Kamp's MD5-crypt
+func md5Crypt(setting, password string) (string, error) {
+	const magic = "$1$"
+
+	salt := strings.TrimPrefix(setting, magic)
+	if end := strings.IndexByte(salt, '$'); end >= 0 {
+		salt = salt[:end]
+	}
+	if len(salt) > md5CryptMaxSalt {
+		salt = salt[:md5CryptMaxSalt]
+	}
+
+	p := []byte(password)
+	s := []byte(salt)
+
+	h := md5.New()
+	h.Write(p)
+	h.Write(s)
+	h.Write(p)
+	final := h.Sum(nil)
+
+	h = md5.New()
+	h.Write(p)
+	h.Write([]byte(magic))
+	h.Write(s)
+	for n := len(p); n > 0; n -= md5.Size {
+		h.Write(final[:min(n, md5.Size)])
+	}
+	for n := len(p); n > 0; n >>= 1 {
+		if n&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(p[:1])
+		}
+	}
+	final = h.Sum(nil)
+
+	for i := 0; i < md5CryptRounds; i++ {
+		h = md5.New()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(final)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(final)
+		} else {
+			h.Write(p)
+		}
+		final = h.Sum(nil)
+	}
+
+	var out strings.Builder
+	out.WriteString(magic + salt + "$")
+	for _, group := range md5Order {
+		encode24(&out, final[group[0]], final[group[1]], final[group[2]], 4)
+	}
+	encode24(&out, 0, 0, final[11], 2)
+
+	return out.String(), nil
+}
+
+func repeatTo(digest []byte, length int) []byte {
+	out := make([]byte, 0, length)
+	for len(out) < length {
+		out = append(out, digest[:min(len(digest), length-len(out))]...)
+	}
+	return out
+}
+
+func encode24(out *strings.Builder, b2, b1, b0 byte, n int) {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for ; n > 0; n-- {
+		out.WriteByte(itoa64[w&0x3f])
+		w >>= 6
+	}
+}
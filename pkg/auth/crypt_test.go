@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompareHash(t *testing.T) {
+	// generated with glibc crypt(3) and openssl passwd
+	tests := []struct {
+		name     string
+		hashed   string
+		password string
+	}{
+		{"sha256", "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5", "Hello world!"},
+		{"sha256 rounds, long salt", "$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA", "Hello world!"},
+		{"sha512", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1", "Hello world!"},
+		{"sha512 rounds", "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.", "Hello world!"},
+		{"sha512 default rounds spelled out", "$6$rounds=5000$toolongsaltstrin$lQ8jolhgVRVhY4b5pZKaysCLi0QBxGoNeKQzQ3glMhwllF7oGDZxUhx1yxdYcz/e1JSbq3y6JMxxl8audkUEm0", "This is just a test"},
+		{"sha512 long password", "$6$rounds=1400$anotherlongsalts$POfYwTEok97VWcjxIiSOjiykti.o/pQs.wPvMxQ6Fm7I6IoYN3CmLs66x9t0oSwbtEW7o7UmJEiDwGqd8p4ur1", "a very much longer text to encrypt.  This one even stretches over morethan one line."},
+		{"sha512 empty password", "$6$salt$r6qPcj2UeIkfklWHvleGJk8OKTInFYR/fxyuwcC656IWiZBpIFZ9.hMRG2ZQnnyMFrKOe461f9iT9Ljn0wJ5l.", ""},
+		{"md5", "$1$saltstri$YMyguxXMBpd2TEZ.vS/3q1", "Hello world!"},
+		{"md5 password", "$1$abcdefgh$G//4keteveJp0qb8z2DxG/", "password"},
+		{"md5 empty password", "$1$salt$UsdFqFVB.FsuinRDK5eE..", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CompareHash(tt.hashed, tt.password); err != nil {
+				t.Errorf("CompareHash() with the right password error = %v", err)
+			}
+			if err := CompareHash(tt.hashed, tt.password+"x"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("CompareHash() with a wrong password error = %v, want %v", err, ErrInvalidCredentials)
+			}
+		})
+	}
+}
+
+func TestCompareHashUnsupported(t *testing.T) {
+	for _, hashed := range []string{
+		"$y$j9T$F5Jx5fExrKuPp53xLKQ..1$X3DX6M94c7o.9agCG9G317fhZg9SqC.5i5rd.RhAtQ7", // yescrypt
+		"$2b$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW",              // bcrypt
+		"abJnggxhB/yWI", // DES
+		"$5$rounds=oops",
+	} {
+		if err := CompareHash(hashed, "password"); !errors.Is(err, ErrUnsupportedHash) {
+			t.Errorf("CompareHash(%q) error = %v, want %v", hashed, err, ErrUnsupportedHash)
+		}
+	}
+}
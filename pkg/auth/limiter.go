@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultLockout     = 5 * time.Minute
+	DefaultBackoff     = 500 * time.Millisecond
+)
+
+// LockedOutError - too many failed attempts, verification is refused until the lockout ends
+type LockedOutError struct {
+	Until time.Time
+}
+
+func (e *LockedOutError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again in %s", time.Until(e.Until).Round(time.Second))
+}
+
+// Attempts - failure record of a user
+type Attempts struct {
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until,omitempty"`
+}
+
+// Store - persists failed attempts so the lockout survives between runs
+type Store interface {
+	Load(username string) (Attempts, error)
+	Save(username string, attempts Attempts) error
+}
+
+// RateLimited - wraps a verifier with a growing delay after each failure
+// and a lockout once MaxAttempts failures happen in a row
+type RateLimited struct {
+	Verifier    Verifier
+	Store       Store
+	MaxAttempts int
+	Lockout     time.Duration
+	Backoff     time.Duration
+}
+
+// NewRateLimited - rate limited verifier with the default limits
+func NewRateLimited(verifier Verifier, store Store) *RateLimited {
+	return &RateLimited{
+		Verifier:    verifier,
+		Store:       store,
+		MaxAttempts: DefaultMaxAttempts,
+		Lockout:     DefaultLockout,
+		Backoff:     DefaultBackoff,
+	}
+}
+
+// Verify implements Verifier
+func (r *RateLimited) Verify(ctx context.Context, username, password string) error {
+	now := time.Now()
+
+	attempts, err := r.Store.Load(username)
+	if err != nil {
+		return err
+	}
+
+	if now.Before(attempts.LockedUntil) {
+		return &LockedOutError{Until: attempts.LockedUntil}
+	}
+
+	err = r.Verifier.Verify(ctx, username, password)
+	if err == nil {
+		return r.Store.Save(username, Attempts{})
+	}
+	if !errors.Is(err, ErrInvalidCredentials) {
+		return err
+	}
+
+	attempts.Failures++
+	attempts.LastFailure = now
+	attempts.LockedUntil = time.Time{}
+
+	if r.MaxAttempts > 0 && attempts.Failures >= r.MaxAttempts {
+		attempts.Failures = 0
+		attempts.LockedUntil = now.Add(r.Lockout)
+	}
+
+	if saveErr := r.Store.Save(username, attempts); saveErr != nil {
+		return saveErr
+	}
+
+	// slow down guessing, the delay grows with each failure
+	r.pause(ctx, time.Duration(attempts.Failures)*r.Backoff)
+
+	if !attempts.LockedUntil.IsZero() {
+		return &LockedOutError{Until: attempts.LockedUntil}
+	}
+	return err
+}
+
+func (r *RateLimited) pause(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// MemoryStore - in-process store
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+}
+
+// NewMemoryStore - create an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]Attempts)}
+}
+
+// Load implements Store
+func (s *MemoryStore) Load(username string) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts[username], nil
+}
+
+// Save implements Store
+func (s *MemoryStore) Save(username string, attempts Attempts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts[username] = attempts
+	return nil
+}
+
+// FileStore - JSON file store, readable by the owner only
+type FileStore struct {
+	Path string
+}
+
+// DefaultStorePath - $XDG_STATE_HOME/agent-code/auth.json, defaulting to ~/.local/state
+func DefaultStorePath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(dir, "agent-code", "auth.json"), nil
+}
+
+// Load implements Store
+func (s *FileStore) Load(username string) (Attempts, error) {
+	all, err := s.read()
+	if err != nil {
+		return Attempts{}, err
+	}
+	return all[username], nil
+}
+
+// Save implements Store
+func (s *FileStore) Save(username string, attempts Attempts) error {
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if attempts == (Attempts{}) {
+		delete(all, username)
+	} else {
+		all[username] = attempts
+	}
+
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("error creating auth state directory: %w", err)
+	}
+
+	// write then rename so a crash never leaves a truncated file
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error writing auth state: %w", err)
+	}
+
+	return os.Rename(tmp, s.Path)
+}
+
+func (s *FileStore) read() (map[string]Attempts, error) {
+	all := make(map[string]Attempts)
+
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading auth state: %w", err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, fmt.Errorf("error decoding auth state %s: %w", s.Path, err)
+		}
+	}
+
+	return all, nil
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingVerifier - accepts "secret", fails "broken" as unavailable and counts the calls
+func countingVerifier(calls *int) Verifier {
+	return VerifierFunc(func(ctx context.Context, username, password string) error {
+		*calls++
+		switch password {
+		case "secret":
+			return nil
+		case "broken":
+			return ErrUnavailable
+		}
+		return ErrInvalidCredentials
+	})
+}
+
+func TestRateLimitedLockout(t *testing.T) {
+	var calls int
+	store := NewMemoryStore()
+	r := &RateLimited{Verifier: countingVerifier(&calls), Store: store, MaxAttempts: 3, Lockout: 100 * time.Millisecond}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		password string
+		want     error
+		failures int
+		locked   bool
+	}{
+		{"first wrong password", "guess", ErrInvalidCredentials, 1, false},
+		{"unavailable is not a failure", "broken", ErrUnavailable, 1, false},
+		{"second wrong password", "guess", ErrInvalidCredentials, 2, false},
+		{"third wrong password locks", "guess", &LockedOutError{}, 0, true},
+		{"locked out with the right password", "secret", &LockedOutError{}, 0, true},
+	}
+
+	for _, tt := range tests {
+		err := r.Verify(ctx, "alice", tt.password)
+
+		var lockedOut *LockedOutError
+		if _, wantLocked := tt.want.(*LockedOutError); wantLocked {
+			if !errors.As(err, &lockedOut) {
+				t.Fatalf("%s: Verify() error = %v, want a lockout", tt.name, err)
+			}
+		} else if !errors.Is(err, tt.want) {
+			t.Fatalf("%s: Verify() error = %v, want %v", tt.name, err, tt.want)
+		}
+
+		attempts, _ := store.Load("alice")
+		if attempts.Failures != tt.failures || attempts.LockedUntil.IsZero() == tt.locked {
+			t.Fatalf("%s: attempts = %+v, want %d failures, locked %v", tt.name, attempts, tt.failures, tt.locked)
+		}
+	}
+
+	if calls != 4 {
+		t.Errorf("verifier called %d times, want 4 as locked out attempts never reach it", calls)
+	}
+
+	if other := r.Verify(ctx, "bob", "secret"); other != nil {
+		t.Errorf("Verify() of another user error = %v, the lockout is per user", other)
+	}
+
+	time.Sleep(r.Lockout)
+	if err := r.Verify(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("Verify() after the lockout error = %v", err)
+	}
+	if attempts, _ := store.Load("alice"); attempts != (Attempts{}) {
+		t.Errorf("attempts after a success = %+v, want them cleared", attempts)
+	}
+}
+
+func TestRateLimitedBackoff(t *testing.T) {
+	var calls int
+	backoff := 20 * time.Millisecond
+	r := &RateLimited{Verifier: countingVerifier(&calls), Store: NewMemoryStore(), MaxAttempts: 10, Backoff: backoff}
+
+	for failures := 1; failures <= 3; failures++ {
+		start := time.Now()
+		if err := r.Verify(context.Background(), "alice", "guess"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Verify() error = %v, want %v", err, ErrInvalidCredentials)
+		}
+		if took, want := time.Since(start), time.Duration(failures)*backoff; took < want {
+			t.Errorf("failure %d paused %s, want at least %s", failures, took, want)
+		}
+	}
+
+	// a cancelled context cuts the pause short
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Backoff = time.Hour
+	start := time.Now()
+	if err := r.Verify(ctx, "alice", "guess"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Verify() error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("cancelled Verify() paused %s", took)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "state", "auth.json")}
+
+	if attempts, err := store.Load("alice"); err != nil || attempts != (Attempts{}) {
+		t.Fatalf("Load() without a file = %+v, %v, want no attempts", attempts, err)
+	}
+
+	locked := Attempts{Failures: 2, LastFailure: time.Now().UTC().Truncate(time.Second), LockedUntil: time.Now().Add(time.Minute).UTC().Truncate(time.Second)}
+	if err := store.Save("alice", locked); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save("bob", Attempts{Failures: 1}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("auth state permissions = %o, want 600", perm)
+	}
+
+	got, err := store.Load("alice")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.Failures != locked.Failures || !got.LastFailure.Equal(locked.LastFailure) || !got.LockedUntil.Equal(locked.LockedUntil) {
+		t.Errorf("Load() = %+v, want %+v", got, locked)
+	}
+
+	// a success clears the user without touching the others
+	if err := store.Save("alice", Attempts{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	all, err := store.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := all["alice"]; ok || all["bob"].Failures != 1 {
+		t.Errorf("stored attempts = %+v, want only bob", all)
+	}
+
+	if err := os.WriteFile(store.Path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("alice"); err == nil {
+		t.Error("Load() of a corrupt file succeeded")
+	}
+}
@@ -0,0 +1,91 @@
+//go:build linux && cgo && pam
+
+package auth
+
+/*
+#cgo LDFLAGS: -lpam
+#include <security/pam_appl.h>
+#include <stdlib.h>
+#include <string.h>
+
+// answer every prompt with the password passed as appdata
+static int agent_code_conv(int n, const struct pam_message **msg, struct pam_response **resp, void *appdata) {
+	struct pam_response *replies = calloc(n, sizeof(struct pam_response));
+	if (replies == NULL) {
+		return PAM_BUF_ERR;
+	}
+
+	for (int i = 0; i < n; i++) {
+		if (msg[i]->msg_style == PAM_PROMPT_ECHO_OFF || msg[i]->msg_style == PAM_PROMPT_ECHO_ON) {
+			replies[i].resp = strdup((const char *)appdata);
+			if (replies[i].resp == NULL) {
+				for (int j = 0; j < i; j++) {
+					free(replies[j].resp);
+				}
+				free(replies);
+				return PAM_BUF_ERR;
+			}
+		}
+	}
+
+	*resp = replies;
+	return PAM_SUCCESS;
+}
+
+static int agent_code_authenticate(const char *service, const char *user, const char *password) {
+	struct pam_conv conv = { agent_code_conv, (void *)password };
+	pam_handle_t *handle = NULL;
+
+	int rc = pam_start(service, user, &conv, &handle);
+	if (rc != PAM_SUCCESS) {
+		return rc;
+	}
+
+	rc = pam_authenticate(handle, PAM_SILENT | PAM_DISALLOW_NULL_AUTHTOK);
+	if (rc == PAM_SUCCESS) {
+		rc = pam_acct_mgmt(handle, PAM_SILENT);
+	}
+
+	pam_end(handle, rc);
+	return rc;
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"unsafe"
+)
+
+// pamService - PAM service whose stack is used to authenticate
+const pamService = "login"
+
+// PAM - authenticates through the system PAM stack
+type PAM struct {
+	Service string
+}
+
+// Verify implements Verifier
+func (p *PAM) Verify(ctx context.Context, username, password string) error {
+	service := C.CString(p.Service)
+	defer C.free(unsafe.Pointer(service))
+	cUser := C.CString(username)
+	defer C.free(unsafe.Pointer(cUser))
+	cPassword := C.CString(password)
+	defer C.free(unsafe.Pointer(cPassword))
+
+	rc := C.agent_code_authenticate(service, cUser, cPassword)
+	switch rc {
+	case C.PAM_SUCCESS:
+		return nil
+	case C.PAM_AUTH_ERR, C.PAM_USER_UNKNOWN, C.PAM_MAXTRIES, C.PAM_ACCT_EXPIRED, C.PAM_PERM_DENIED:
+		return ErrInvalidCredentials
+	default:
+		return fmt.Errorf("%w: %s", ErrUnavailable, C.GoString(C.pam_strerror(nil, rc)))
+	}
+}
+
+func pamVerifier() Verifier {
+	return &PAM{Service: pamService}
+}
@@ -0,0 +1,8 @@
+//go:build !(linux && cgo && pam)
+
+package auth
+
+// pamVerifier - PAM support needs cgo and the pam build tag (go build -tags pam)
+func pamVerifier() Verifier {
+	return nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultShadowPath - linux shadow password file, only readable when privileged
+const DefaultShadowPath = "/etc/shadow"
+
+// Shadow - compares against the hash stored in the shadow file
+type Shadow struct {
+	Path string
+}
+
+// Verify implements Verifier
+func (s *Shadow) Verify(ctx context.Context, username, password string) error {
+	hashed, err := s.lookup(username)
+	if err != nil {
+		return err
+	}
+
+	// locked or password-less accounts cannot authenticate with a password
+	if hashed == "" || strings.HasPrefix(hashed, "!") || strings.HasPrefix(hashed, "*") {
+		return ErrInvalidCredentials
+	}
+
+	err = CompareHash(hashed, password)
+	if errors.Is(err, ErrUnsupportedHash) {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
+
+// lookup - hash field of a user, ErrUnavailable when the file cannot be read
+func (s *Shadow) lookup(username string) (string, error) {
+	path := s.Path
+	if path == "" {
+		path = DefaultShadowPath
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) > 1 && fields[0] == username {
+			return fields[1], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	return "", fmt.Errorf("%w: user %s is not in %s", ErrUnavailable, username, path)
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// sudoTimeout - sudo waits a few seconds after a wrong password, keep some room for that
+const sudoTimeout = 15 * time.Second
+
+// Sudo - validates the password with `sudo -S -k true`. only works for users allowed to run
+// true through sudo, and not at all for root which sudo never asks a password from
+type Sudo struct{}
+
+// Verify implements Verifier
+func (s *Sudo) Verify(ctx context.Context, username, password string) error {
+	if os.Geteuid() == 0 {
+		return fmt.Errorf("%w: sudo does not ask root for a password", ErrUnavailable)
+	}
+
+	if current, err := user.Current(); err != nil || current.Username != username {
+		return fmt.Errorf("%w: sudo can only verify the current user", ErrUnavailable)
+	}
+
+	path, err := exec.LookPath("sudo")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sudoTimeout)
+	defer cancel()
+
+	// -k with a command ignores cached credentials so the password is always checked, and unlike -v
+	// it neither starts nor extends the sudo session. -p "" hides the prompt
+	cmd := exec.CommandContext(ctx, path, "-S", "-k", "-p", "", "--", "true")
+	cmd.Stdin = strings.NewReader(password + "\n")
+	cmd.Env = []string{"LC_ALL=C", "PATH=" + os.Getenv("PATH")}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return sudoFailure(stderr.String())
+		}
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	return nil
+}
+
+// sudoFailure - why sudo refused. only a rejected password is ErrInvalidCredentials, a user sudo
+// does not let in or a missing tty says nothing about the password and must not count as a failure
+func sudoFailure(stderr string) error {
+	for _, wrong := range []string{"Sorry, try again", "incorrect password attempt"} {
+		if strings.Contains(stderr, wrong) {
+			return ErrInvalidCredentials
+		}
+	}
+
+	// the last line is the error, the lecture of a first sudo run comes before it
+	message := strings.TrimSpace(stderr)
+	if i := strings.LastIndexByte(message, '\n'); i >= 0 {
+		message = strings.TrimSpace(message[i+1:])
+	}
+	if message == "" {
+		message = "sudo failed without saying why"
+	}
+	return fmt.Errorf("%w: %s", ErrUnavailable, strings.TrimPrefix(message, "sudo: "))
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSudoFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		stderr  string
+		want    error
+		message string
+	}{
+		{"wrong password", "Sorry, try again.\nsudo: 1 incorrect password attempt\n", ErrInvalidCredentials, ""},
+		{"wrong password, newer sudo", "sudo: 1 incorrect password attempt\n", ErrInvalidCredentials, ""},
+		{"not a sudoer", "alice is not in the sudoers file.  This incident will be reported.\n", ErrUnavailable, "not in the sudoers file"},
+		{"lecture before the error", "\nWe trust you have received the usual lecture...\n\nsudo: no password was provided\n", ErrUnavailable, ": no password was provided"},
+		{"no terminal", "sudo: a terminal is required to read the password\n", ErrUnavailable, ": a terminal is required"},
+		{"silent", "", ErrUnavailable, "without saying why"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sudoFailure(tt.stderr)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("sudoFailure() = %v, want %v", err, tt.want)
+			}
+			if errors.Is(tt.want, ErrUnavailable) && errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("sudoFailure() = %v counts as a wrong password", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("sudoFailure() = %q, want it to contain %q", err, tt.message)
+			}
+		})
+	}
+}
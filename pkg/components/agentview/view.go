@@ -0,0 +1,243 @@
+package agentview
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/agent"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"strings"
+)
+
+// maxResultLines - tool output lines shown per call, the model still receives everything
+const maxResultLines = 8
+
+// EventMsg - progress event from the agent loop
+type EventMsg struct {
+	Event agent.Event
+}
+
+// ApprovalMsg - the agent waits for the user to approve a mutating tool call,
+// Preview shows what the call would change when it can be told beforehand
+type ApprovalMsg struct {
+	Call    provider.ToolCall
+	Preview string
+	Reply   chan<- bool
+}
+
+// DoneMsg - the agent loop finished
+type DoneMsg struct {
+	Err error
+}
+
+type entryKind int
+
+const (
+	textEntry entryKind = iota
+	callEntry
+	resultEntry
+)
+
+type entry struct {
+	kind     entryKind
+	text     string
+	call     provider.ToolCall
+	mutating bool
+	err      error
+	denied   bool
+}
+
+var faintStyle = lipgloss.NewStyle().Faint(true)
+
+type Model struct {
+	prompt    string
+	entries   []entry
+	pending   *ApprovalMsg
+	width     int
+	steps     int
+	usage     provider.Usage
+	done      bool
+	cancelled bool
+	err       error
+	cancel    context.CancelFunc
+}
+
+// InitialAgentViewModel - transcript of an agent run, cancel stops the loop when the user quits
+func InitialAgentViewModel(prompt string, cancel context.CancelFunc) *Model {
+	return &Model{
+		prompt: prompt,
+		cancel: cancel,
+	}
+}
+
+// Cancelled - user quit before the agent finished
+func (m *Model) Cancelled() bool {
+	return m.cancelled
+}
+
+// Err - error that ended the run, if any
+func (m *Model) Err() error {
+	return m.err
+}
+
+// Init implements tea.Model
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			if m.pending != nil {
+				m.reply(false)
+			}
+			if !m.done {
+				m.cancelled = true
+				if m.cancel != nil {
+					m.cancel()
+				}
+			}
+			return m, tea.Quit
+		case "y", "Y":
+			if m.pending != nil {
+				m.reply(true)
+			}
+		case "n", "N":
+			if m.pending != nil {
+				m.reply(false)
+			}
+		}
+
+	case ApprovalMsg:
+		m.pending = &msg
+
+	case EventMsg:
+		m.addEvent(msg.Event)
+
+	case DoneMsg:
+		m.done = true
+		if msg.Err != nil && !m.cancelled {
+			m.err = msg.Err
+		}
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+func (m *Model) reply(approved bool) {
+	m.pending.Reply <- approved
+	m.pending = nil
+}
+
+func (m *Model) addEvent(event agent.Event) {
+	switch e := event.(type) {
+	case agent.TextEvent:
+		// streamed text joins the previous text block
+		if n := len(m.entries); n > 0 && m.entries[n-1].kind == textEntry {
+			m.entries[n-1].text += e.Text
+			return
+		}
+		m.entries = append(m.entries, entry{kind: textEntry, text: e.Text})
+	case agent.ToolCallEvent:
+		m.entries = append(m.entries, entry{kind: callEntry, call: e.Call, mutating: e.Mutating})
+	case agent.ToolResultEvent:
+		m.entries = append(m.entries, entry{kind: resultEntry, call: e.Call, text: e.Result, err: e.Err, denied: e.Denied})
+	case agent.StepEvent:
+		m.steps = e.Step
+		m.usage.PromptTokens += e.Usage.PromptTokens
+		m.usage.CompletionTokens += e.Usage.CompletionTokens
+		m.usage.TotalTokens += e.Usage.TotalTokens
+	}
+}
+
+// View implements tea.Model
+func (m *Model) View() string {
+	var s strings.Builder
+	s.WriteString(ui.RenderHeader("Agent") + "\n")
+	s.WriteString(ui.SuccessStyle2.Render("› ") + ui.TextStyle.Render(m.prompt) + "\n\n")
+
+	text := ui.TextStyle
+	if m.width > 0 {
+		text = text.Width(m.width - 1)
+	}
+
+	for _, e := range m.entries {
+		switch e.kind {
+		case textEntry:
+			s.WriteString(text.Render(strings.TrimSpace(e.text)) + "\n\n")
+		case callEntry:
+			s.WriteString(RenderToolCall(e.call, e.mutating) + "\n")
+		case resultEntry:
+			switch {
+			case e.denied:
+				s.WriteString(ui.ErrorStyle.UnsetMargins().Render("  ↳ denied") + "\n\n")
+			case e.err != nil:
+				s.WriteString(ui.ErrorStyle.UnsetMargins().Render("  ↳ "+e.err.Error()) + "\n\n")
+			default:
+				s.WriteString(faintStyle.Render(indentResult(e.text)) + "\n\n")
+			}
+		}
+	}
+
+	switch {
+	case m.pending != nil:
+		if m.pending.Preview != "" {
+			s.WriteString(m.pending.Preview + "\n")
+		}
+		s.WriteString(ui.RenderInfo(fmt.Sprintf("allow %s? press [Y] to approve, [N] to deny", m.pending.Call.Name)) + "\n")
+	case m.err != nil:
+		s.WriteString(ui.RenderError(m.err.Error()) + "\n")
+	case m.cancelled:
+		s.WriteString(ui.RenderError("cancelled") + "\n")
+	case m.done:
+		s.WriteString(faintStyle.Render(fmt.Sprintf("%d steps, %d tokens", m.steps, m.usage.TotalTokens)) + "\n")
+	default:
+		s.WriteString(ui.RenderInfo("working... (press esc/ctrl+c to stop)") + "\n")
+	}
+
+	return s.String()
+}
+
+// RenderToolCall - tool name and its arguments, mutating tools are highlighted
+func RenderToolCall(call provider.ToolCall, mutating bool) string {
+	name := ui.InfoStyle.Render("⚙ " + call.Name)
+	if mutating {
+		name = ui.ErrorStyle.UnsetMargins().Render("⚠ " + call.Name)
+	}
+
+	return fmt.Sprintf("%s %s", name, ui.RenderCode(call.Arguments))
+}
+
+// indentResult - first lines of a tool result, indented under the call
+func indentResult(result string) string {
+	lines := strings.Split(strings.TrimRight(result, "\n"), "\n")
+
+	more := 0
+	if len(lines) > maxResultLines {
+		more = len(lines) - maxResultLines
+		lines = lines[:maxResultLines]
+	}
+
+	for i, line := range lines {
+		prefix := "    "
+		if i == 0 {
+			prefix = "  ↳ "
+		}
+		lines[i] = prefix + line
+	}
+
+	if more > 0 {
+		lines = append(lines, fmt.Sprintf("    … %d more lines", more))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,234 @@
+package diffview
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/diff"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"strings"
+)
+
+// decision - what the user made of a hunk
+type decision int
+
+const (
+	undecided decision = iota
+	accepted
+	rejected
+)
+
+var (
+	faintStyle    = lipgloss.NewStyle().Faint(true)
+	statusStyle   = lipgloss.NewStyle().Reverse(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true).Bold(true)
+)
+
+type Model struct {
+	path      string
+	hunks     []diff.Hunk
+	decisions []decision
+	current   int
+
+	viewport  viewport.Model
+	hunkLines []int // first line of every hunk in the rendered diff
+	width     int
+	height    int
+
+	confirmed bool
+	cancelled bool
+}
+
+// InitialDiffViewModel - review of the hunks of a change to path, every hunk is accepted or
+// rejected on its own and nothing is written here, see Accepted once the program is done
+func InitialDiffViewModel(path string, hunks []diff.Hunk) *Model {
+	return &Model{
+		path:      path,
+		hunks:     hunks,
+		decisions: make([]decision, len(hunks)),
+		viewport:  viewport.New(0, 0),
+	}
+}
+
+// Accepted - which hunks to apply, undecided ones are left out
+func (m *Model) Accepted() []bool {
+	accept := make([]bool, len(m.decisions))
+	for i, d := range m.decisions {
+		accept[i] = d == accepted
+	}
+	return accept
+}
+
+// Confirmed - the user asked to write the accepted hunks
+func (m *Model) Confirmed() bool {
+	return m.confirmed
+}
+
+// Cancelled - the user quit without writing
+func (m *Model) Cancelled() bool {
+	return m.cancelled
+}
+
+// Init implements tea.Model
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = max(msg.Height-2, 1) // header and status lines
+		m.viewport.Width = m.width
+		m.viewport.Height = m.height
+		m.render()
+
+	case tea.MouseMsg:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.cancelled = true
+			return m, tea.Quit
+		case "enter", "w":
+			m.confirmed = true
+			return m, tea.Quit
+		case "down", "j", "tab":
+			m.move(1)
+		case "up", "k", "shift+tab":
+			m.move(-1)
+		case "y":
+			m.decide(accepted)
+		case "n":
+			m.decide(rejected)
+		case " ":
+			if m.decisions[m.current] == accepted {
+				m.decisions[m.current] = rejected
+			} else {
+				m.decisions[m.current] = accepted
+			}
+			m.render()
+		case "a":
+			m.decideAll(accepted)
+		case "d":
+			m.decideAll(rejected)
+		case "pgdown", "f", "ctrl+f", "ctrl+d":
+			m.viewport.HalfViewDown()
+		case "pgup", "b", "ctrl+b", "ctrl+u":
+			m.viewport.HalfViewUp()
+		}
+	}
+
+	return m, nil
+}
+
+// decide - settle the current hunk and go on to the next one
+func (m *Model) decide(d decision) {
+	m.decisions[m.current] = d
+	m.move(1)
+}
+
+func (m *Model) decideAll(d decision) {
+	for i := range m.decisions {
+		m.decisions[i] = d
+	}
+	m.render()
+}
+
+func (m *Model) move(delta int) {
+	m.current = min(max(m.current+delta, 0), len(m.hunks)-1)
+	m.render()
+}
+
+// render - the diff with the current hunk marked, scrolled so the hunk is in view
+func (m *Model) render() {
+	var lines []string
+	m.hunkLines = m.hunkLines[:0]
+
+	for i, hunk := range m.hunks {
+		m.hunkLines = append(m.hunkLines, len(lines))
+
+		marker, tag := "  ", ""
+		switch m.decisions[i] {
+		case accepted:
+			tag = ui.DiffAddStyle.Render(" [accept]")
+		case rejected:
+			tag = ui.DiffRemoveStyle.Render(" [reject]")
+		}
+		header := ui.DiffHunkStyle.Render(hunk.Header())
+		if i == m.current {
+			marker = "▶ "
+			header = selectedStyle.Render(hunk.Header())
+		}
+		lines = append(lines, marker+header+tag)
+
+		for _, line := range hunk.Lines {
+			text := string(line.Kind) + ansi.Strip(strings.TrimRight(line.Text, "\r\n"))
+			text = strings.ReplaceAll(text, "\t", "    ")
+			switch line.Kind {
+			case '+':
+				text = ui.DiffAddStyle.Render(text)
+			case '-':
+				text = ui.DiffRemoveStyle.Render(text)
+			default:
+				text = faintStyle.Render(text)
+			}
+			lines = append(lines, "  "+ansi.Truncate(text, max(m.width-2, 0), "…"))
+		}
+		lines = append(lines, "")
+	}
+
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+
+	// the current hunk starts near the top unless all of it already shows
+	if len(m.hunkLines) > 0 {
+		start := m.hunkLines[m.current]
+		end := len(lines)
+		if m.current+1 < len(m.hunkLines) {
+			end = m.hunkLines[m.current+1]
+		}
+		if start < m.viewport.YOffset || end > m.viewport.YOffset+m.height {
+			m.viewport.SetYOffset(max(start-1, 0))
+		}
+	}
+}
+
+// View implements tea.Model
+func (m *Model) View() string {
+	if m.width == 0 {
+		return "loading..."
+	}
+
+	added, removed := 0, 0
+	for _, hunk := range m.hunks {
+		a, r := hunk.Stats()
+		added += a
+		removed += r
+	}
+	stats := " " + ui.DiffAddStyle.Render(fmt.Sprintf("+%d", added)) + " " + ui.DiffRemoveStyle.Render(fmt.Sprintf("-%d", removed))
+	path := ansi.Truncate(m.path, max(m.width-lipgloss.Width(stats), 0), "…")
+	header := ui.HeaderStyle.UnsetPadding().Render(path) + stats
+
+	return header + "\n" + m.viewport.View() + "\n" + m.statusLine()
+}
+
+func (m *Model) statusLine() string {
+	count := 0
+	for _, d := range m.decisions {
+		if d == accepted {
+			count++
+		}
+	}
+
+	position := fmt.Sprintf(" hunk %d/%d  %d accepted ", m.current+1, len(m.hunks), count)
+	help := " y accept  n reject  space toggle  a/d all  ↑/↓ move  enter write  q quit "
+
+	gap := max(m.width-lipgloss.Width(position)-lipgloss.Width(help), 0)
+	return statusStyle.Render(ansi.Truncate(help+strings.Repeat(" ", gap)+position, m.width, ""))
+}
@@ -0,0 +1,396 @@
+package fileview
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineBytes - longer lines are cut for display, searching still sees the whole line
+	maxLineBytes = 16 << 10
+	tabWidth     = 4
+	readChunk    = 64 << 10
+	hexWidth     = 16
+)
+
+// document - lines of a file read on demand
+type document interface {
+	lineCount() int
+
+	// lines - display text of lines [start, end)
+	lines(start, end int) ([]string, error)
+
+	// scan - call fn for each full line in [start, end) until it returns false
+	scan(start, end int, fn func(i int, line []byte) bool) error
+
+	Close() error
+}
+
+// openDocument - binaries are shown as a hex dump, UTF-16 text is transcoded to UTF-8
+func openDocument(path string) (document, filesystem.Content, error) {
+	content, err := filesystem.Sniff(path)
+	if err != nil {
+		return nil, content, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, content, err
+	}
+
+	var doc document
+	switch content.Encoding {
+	case filesystem.EncodingBinary:
+		return &hexSource{reader: file, closer: file, size: content.Size}, content, nil
+	case filesystem.EncodingUTF16LE, filesystem.EncodingUTF16BE:
+		// line offsets only work on UTF-8, the whole file is decoded up front
+		data, readErr := io.ReadAll(file)
+		_ = file.Close()
+		if readErr != nil {
+			return nil, content, readErr
+		}
+		text := []byte(filesystem.DecodeText(data, content.Encoding))
+		doc, err = newSource(bytes.NewReader(text), nil, int64(len(text)))
+	default:
+		bom := int64(content.BOMLen())
+		doc, err = newSource(io.NewSectionReader(file, bom, content.Size-bom), file, content.Size-bom)
+	}
+
+	if err != nil {
+		_ = file.Close()
+		return nil, content, err
+	}
+	return doc, content, nil
+}
+
+// source - text read on demand through an index of line offsets, never loaded as a whole
+type source struct {
+	reader  io.ReaderAt
+	closer  io.Closer
+	size    int64
+	offsets []int64 // byte offset of the start of each line
+}
+
+func newSource(reader io.ReaderAt, closer io.Closer, size int64) (*source, error) {
+	src := &source{reader: reader, closer: closer, size: size}
+	if err := src.index(); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// index - record where every line starts, reading the file once in chunks
+func (s *source) index() error {
+	reader := io.NewSectionReader(s.reader, 0, s.size)
+	buf := make([]byte, readChunk)
+	lineStart := true
+
+	var pos int64
+	for {
+		n, err := reader.Read(buf)
+		chunk := buf[:n]
+		for len(chunk) > 0 {
+			if lineStart {
+				s.offsets = append(s.offsets, pos+int64(n-len(chunk)))
+				lineStart = false
+			}
+
+			i := bytes.IndexByte(chunk, '\n')
+			if i < 0 {
+				break
+			}
+			chunk = chunk[i+1:]
+			lineStart = true
+		}
+		pos += int64(n)
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *source) lineCount() int {
+	return len(s.offsets)
+}
+
+func (s *source) lineEnd(i int) int64 {
+	if i+1 < len(s.offsets) {
+		return s.offsets[i+1]
+	}
+	return s.size
+}
+
+// lines - display text of lines [start, end), cut at maxLineBytes with tabs expanded
+func (s *source) lines(start, end int) ([]string, error) {
+	out := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		length := s.lineEnd(i) - s.offsets[i]
+		cut := length > maxLineBytes
+		if cut {
+			length = maxLineBytes
+		}
+
+		buf := make([]byte, length)
+		if _, err := s.reader.ReadAt(buf, s.offsets[i]); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		line := displayText(buf)
+		if cut {
+			line += "…"
+		}
+		out = append(out, line)
+	}
+
+	return out, nil
+}
+
+// scan - call fn for each full line in [start, end) until it returns false
+func (s *source) scan(start, end int, fn func(i int, line []byte) bool) error {
+	if start >= end {
+		return nil
+	}
+
+	section := io.NewSectionReader(s.reader, s.offsets[start], s.lineEnd(end-1)-s.offsets[start])
+	reader := bufio.NewReaderSize(section, readChunk)
+
+	var long []byte
+	for i := start; i < end; {
+		chunk, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// line longer than the buffer, keep collecting it
+			long = append(long, chunk...)
+			continue
+		}
+
+		line := chunk
+		if long != nil {
+			line = append(long, chunk...)
+			long = nil
+		}
+
+		if len(line) > 0 || err == nil {
+			if !fn(i, line) {
+				return nil
+			}
+			i++
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *source) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// hexSource - a binary file as rows of hexWidth bytes: offset, hex bytes and the printable characters
+type hexSource struct {
+	reader io.ReaderAt
+	closer io.Closer
+	size   int64
+}
+
+func (h *hexSource) lineCount() int {
+	return int((h.size + hexWidth - 1) / hexWidth)
+}
+
+func (h *hexSource) lines(start, end int) ([]string, error) {
+	from := int64(start) * hexWidth
+	buf := make([]byte, min(int64(end)*hexWidth, h.size)-from)
+	if _, err := h.reader.ReadAt(buf, from); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	out := make([]string, 0, end-start)
+	for offset := 0; offset < len(buf); offset += hexWidth {
+		out = append(out, hexRow(from+int64(offset), buf[offset:min(offset+hexWidth, len(buf))]))
+	}
+	return out, nil
+}
+
+// scan - rows are matched as displayed, so searching finds hex bytes and text alike
+func (h *hexSource) scan(start, end int, fn func(i int, line []byte) bool) error {
+	for from := start; from < end; from += readChunk / hexWidth {
+		to := min(from+readChunk/hexWidth, end)
+		rows, err := h.lines(from, to)
+		if err != nil {
+			return err
+		}
+		for i, row := range rows {
+			if !fn(from+i, []byte(row)) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (h *hexSource) Close() error {
+	return h.closer.Close()
+}
+
+// hexRow - 00000010  48 65 6c 6c 6f 0a 00 00  ...  |Hello...|
+func hexRow(offset int64, row []byte) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%08x  ", offset)
+
+	for i := 0; i < hexWidth; i++ {
+		if i < len(row) {
+			fmt.Fprintf(&out, "%02x ", row[i])
+		} else {
+			out.WriteString("   ")
+		}
+		if i == hexWidth/2-1 {
+			out.WriteString(" ")
+		}
+	}
+
+	out.WriteString(" |")
+	for _, b := range row {
+		if b < 0x20 || b > 0x7e {
+			b = '.'
+		}
+		out.WriteByte(b)
+	}
+	out.WriteString("|")
+
+	return out.String()
+}
+
+// displayText - strip the line ending, expand tabs and replace what the terminal cannot show
+func displayText(b []byte) string {
+	b = bytes.TrimRight(b, "\r\n")
+	if !utf8.Valid(b) {
+		b = bytes.ToValidUTF8(b, []byte("�"))
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' || r == 0x7f {
+			return '.'
+		}
+		return r
+	}, strings.ReplaceAll(string(b), "\t", strings.Repeat(" ", tabWidth)))
+}
+
+// highlighter - chroma lexer and style turned into lipgloss styles, nil style means plain text
+type highlighter struct {
+	label  string
+	lexer  chroma.Lexer
+	style  *chroma.Style
+	styles map[chroma.TokenType]lipgloss.Style
+}
+
+// newHighlighter - lexer picked by file name, falling back to the content of the first lines
+func newHighlighter(fileName, sample, styleName string) *highlighter {
+	lexer := lexers.Match(fileName)
+	if lexer == nil {
+		lexer = lexers.Analyse(sample)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	h := &highlighter{
+		label:  lexer.Config().Name,
+		lexer:  chroma.Coalesce(lexer),
+		styles: make(map[chroma.TokenType]lipgloss.Style),
+	}
+	if styleName != "" {
+		h.style = styles.Get(styleName)
+	}
+
+	return h
+}
+
+// name - language name shown in the status bar
+func (h *highlighter) name() string {
+	return h.label
+}
+
+// highlight - style lines as one block so multi line tokens keep their colour, returns one entry per line
+func (h *highlighter) highlight(lines []string) []string {
+	if h.style == nil || len(lines) == 0 {
+		return lines
+	}
+
+	iterator, err := h.lexer.Tokenise(nil, strings.Join(lines, "\n"))
+	if err != nil {
+		return lines
+	}
+
+	out := make([]string, len(lines))
+	var current strings.Builder
+	row := 0
+
+	for _, token := range iterator.Tokens() {
+		for i, part := range strings.Split(token.Value, "\n") {
+			if i > 0 {
+				if row < len(out) {
+					out[row] = current.String()
+				}
+				current.Reset()
+				row++
+			}
+			if part != "" {
+				current.WriteString(h.styleFor(token.Type).Render(part))
+			}
+		}
+	}
+	if row < len(out) {
+		out[row] = current.String()
+	}
+
+	return out
+}
+
+func (h *highlighter) styleFor(tokenType chroma.TokenType) lipgloss.Style {
+	if style, ok := h.styles[tokenType]; ok {
+		return style
+	}
+
+	entry := h.style.Get(tokenType)
+	style := lipgloss.NewStyle()
+	if entry.Colour.IsSet() {
+		style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+	if entry.Bold == chroma.Yes {
+		style = style.Bold(true)
+	}
+	if entry.Italic == chroma.Yes {
+		style = style.Italic(true)
+	}
+	if entry.Underline == chroma.Yes {
+		style = style.Underline(true)
+	}
+
+	h.styles[tokenType] = style
+	return style
+}
@@ -0,0 +1,527 @@
+package fileview
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// windowLines - lines read and highlighted around the visible ones, the rest of the file stays on disk
+	windowLines    = 500
+	horizontalStep = 8
+	sampleLines    = 20
+)
+
+// mode
+type mode int
+
+const (
+	normalMode mode = iota
+	searchMode
+	jumpMode
+)
+
+// searchResultMsg - result of a search running in the background
+type searchResultMsg struct {
+	query   string
+	line    int
+	found   bool
+	wrapped bool
+	err     error
+}
+
+// editorDoneMsg - the editor the file was handed to exited
+type editorDoneMsg struct {
+	err error
+}
+
+var (
+	faintStyle  = lipgloss.NewStyle().Faint(true)
+	statusStyle = lipgloss.NewStyle().Reverse(true)
+)
+
+type Model struct {
+	path     string
+	src      document
+	content  filesystem.Content
+	hl       *highlighter
+	viewport viewport.Model
+	input    textinput.Model
+	mode     mode
+
+	winStart int
+	winLines []string // highlighted lines of the loaded window
+
+	top     int
+	xOffset int
+	width   int
+	height  int
+
+	query     string
+	mark      int // line of the last search match or jump, -1 for none
+	searching bool
+	status    string
+	err       error
+
+	editorName string
+	editorFunc func(line int) (*exec.Cmd, error)
+}
+
+// InitialFileViewModel - scrollable viewer of a file with syntax highlighting,
+// the file is indexed once and only the lines around the visible ones are read
+func InitialFileViewModel(path string) (*Model, error) {
+	src, content, err := openDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hl := &highlighter{label: "hex"}
+	if !content.Binary() {
+		sample, err := src.lines(0, min(sampleLines, src.lineCount()))
+		if err != nil {
+			_ = src.Close()
+			return nil, err
+		}
+		hl = newHighlighter(filepath.Base(path), strings.Join(sample, "\n"), ui.SyntaxStyle)
+	}
+
+	ti := textinput.New()
+	ti.CharLimit = 256
+
+	return &Model{
+		path:     path,
+		src:      src,
+		content:  content,
+		hl:       hl,
+		viewport: viewport.New(0, 0),
+		input:    ti,
+		mark:     -1,
+		winStart: -1,
+	}, nil
+}
+
+// WithEditor - e hands the file to an editor at the current line, the viewer is suspended
+// while it runs and the file is read again once it exits
+func (m *Model) WithEditor(name string, editorFunc func(line int) (*exec.Cmd, error)) *Model {
+	m.editorName = name
+	m.editorFunc = editorFunc
+	return m
+}
+
+// Goto - start at a line, 1 based
+func (m *Model) Goto(line int) *Model {
+	if line > 0 {
+		m.top = line - 1
+		m.mark = line - 1
+	}
+	return m
+}
+
+// Close - release the file, call once the program is done
+func (m *Model) Close() error {
+	return m.src.Close()
+}
+
+// Err - error raised while reading the file, if any
+func (m *Model) Err() error {
+	return m.err
+}
+
+// Init implements tea.Model
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = max(msg.Height-2, 1) // header and status lines
+		m.viewport.Width = m.width
+		m.viewport.Height = m.height
+		m.scrollTo(m.top)
+
+	case tea.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.scrollTo(m.top - 3)
+		case tea.MouseButtonWheelDown:
+			m.scrollTo(m.top + 3)
+		}
+
+	case searchResultMsg:
+		m.searching = false
+		switch {
+		case msg.err != nil:
+			m.status = "search failed: " + msg.err.Error()
+		case !msg.found:
+			m.status = "pattern not found: " + msg.query
+		default:
+			m.mark = msg.line
+			m.status = fmt.Sprintf("match at line %d", msg.line+1)
+			if m.content.Binary() {
+				m.status = fmt.Sprintf("match at offset %#x", msg.line*hexWidth)
+			}
+			if msg.wrapped {
+				m.status += " (search wrapped)"
+			}
+			m.scrollTo(msg.line - m.height/3)
+		}
+
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("%s failed: %v", m.editorName, msg.err)
+		}
+		m.reload()
+
+	case tea.KeyMsg:
+		if m.mode != normalMode {
+			return m.updatePrompt(msg)
+		}
+		return m.updateNormal(msg)
+	}
+
+	return m, nil
+}
+
+func (m *Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case "down", "j", "enter":
+		m.scrollTo(m.top + 1)
+	case "up", "k":
+		m.scrollTo(m.top - 1)
+	case "pgdown", " ", "f", "ctrl+f":
+		m.scrollTo(m.top + m.height)
+	case "pgup", "b", "ctrl+b":
+		m.scrollTo(m.top - m.height)
+	case "ctrl+d":
+		m.scrollTo(m.top + m.height/2)
+	case "ctrl+u":
+		m.scrollTo(m.top - m.height/2)
+	case "home", "g":
+		m.scrollTo(0)
+	case "end", "G":
+		m.scrollTo(m.src.lineCount())
+	case "right", "l":
+		m.xOffset += horizontalStep
+		m.render()
+	case "left", "h":
+		m.xOffset = max(m.xOffset-horizontalStep, 0)
+		m.render()
+	case "/":
+		return m, m.prompt(searchMode, "/")
+	case ":":
+		return m, m.prompt(jumpMode, ":")
+	case "e":
+		return m, m.edit()
+	case "n":
+		return m, m.search(m.query, m.searchFrom(), false)
+	case "N":
+		return m, m.search(m.query, m.searchFrom(), true)
+	}
+
+	return m, nil
+}
+
+func (m *Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.mode = normalMode
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		current := m.mode
+		m.mode = normalMode
+		m.input.Blur()
+
+		if current == searchMode {
+			if value != "" {
+				m.query = value
+			}
+			// a new search starts from the top of the screen
+			return m, m.search(m.query, m.top-1, false)
+		}
+
+		m.jump(value)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) prompt(md mode, prefix string) tea.Cmd {
+	m.mode = md
+	m.status = ""
+	m.input.Prompt = prefix
+	m.input.SetValue("")
+	return m.input.Focus()
+}
+
+// jump - :N moves line N to the top of the screen, in a hex dump N is a byte offset, e.g. :0x1f0
+func (m *Model) jump(value string) {
+	if m.content.Binary() {
+		offset, err := strconv.ParseInt(value, 0, 64)
+		if err != nil || offset < 0 {
+			m.status = fmt.Sprintf("invalid offset: %s", value)
+			return
+		}
+		m.mark = int(offset / hexWidth)
+		m.status = ""
+		m.scrollTo(m.mark)
+		return
+	}
+
+	line, err := strconv.Atoi(value)
+	if err != nil || line < 1 {
+		m.status = fmt.Sprintf("invalid line number: %s", value)
+		return
+	}
+
+	line = min(line, m.src.lineCount())
+	m.mark = line - 1
+	m.status = ""
+	m.scrollTo(line - 1)
+}
+
+// edit - hand the file to the editor at the marked line, or the top of the screen
+func (m *Model) edit() tea.Cmd {
+	if m.editorFunc == nil {
+		m.status = "no editor available, set $EDITOR"
+		return nil
+	}
+
+	line := m.top + 1
+	if m.mark >= m.top && m.mark < m.top+m.height {
+		line = m.mark + 1
+	}
+
+	cmd, err := m.editorFunc(line)
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorDoneMsg{err: err}
+	})
+}
+
+// reload - read the file again after it was edited, keeping the position
+func (m *Model) reload() {
+	src, content, err := openDocument(m.path)
+	if err != nil {
+		m.status = "read failed: " + err.Error()
+		return
+	}
+
+	_ = m.src.Close()
+	m.src = src
+	m.content = content
+	m.winStart = -1
+	m.winLines = nil
+	m.scrollTo(m.top)
+}
+
+// searchFrom - n and N continue from the last match, or the top of the screen
+func (m *Model) searchFrom() int {
+	if m.mark >= 0 {
+		return m.mark
+	}
+	return m.top - 1
+}
+
+// search - find the next (or previous) line after from containing query in the background, wrapping around
+func (m *Model) search(query string, from int, backward bool) tea.Cmd {
+	if query == "" || m.searching {
+		return nil
+	}
+	m.searching = true
+	m.status = "searching..."
+
+	src := m.src
+
+	return func() tea.Msg {
+		match := matcher(query)
+		total := src.lineCount()
+		result := searchResultMsg{query: query, line: -1}
+
+		find := func(start, end int) error {
+			return src.scan(start, end, func(i int, line []byte) bool {
+				if match(line) {
+					result.line = i
+					result.found = true
+					// forward stops at the first match, backward keeps the last one
+					return backward
+				}
+				return true
+			})
+		}
+
+		if backward {
+			result.err = find(0, max(from, 0))
+			if !result.found && result.err == nil {
+				result.wrapped = true
+				result.err = find(max(from, 0), total)
+			}
+			return result
+		}
+
+		result.err = find(from+1, total)
+		if !result.found && result.err == nil {
+			result.wrapped = true
+			result.err = find(0, min(from+1, total))
+		}
+		return result
+	}
+}
+
+// matcher - smart case: case is ignored unless the query has upper case letters
+func matcher(query string) func([]byte) bool {
+	if strings.IndexFunc(query, unicode.IsUpper) >= 0 {
+		needle := []byte(query)
+		return func(line []byte) bool { return bytes.Contains(line, needle) }
+	}
+
+	needle := []byte(strings.ToLower(query))
+	return func(line []byte) bool { return bytes.Contains(bytes.ToLower(line), needle) }
+}
+
+// scrollTo - move the top line, loading another window of the file when it leaves the loaded one
+func (m *Model) scrollTo(top int) {
+	total := m.src.lineCount()
+	m.top = max(min(top, total-m.height), 0)
+
+	winEnd := m.winStart + len(m.winLines)
+	if m.winStart < 0 || m.top < m.winStart || (m.top+m.height > winEnd && winEnd < total) {
+		start := max(m.top-windowLines/4, 0)
+		lines, err := m.src.lines(start, min(start+windowLines, total))
+		if err != nil {
+			m.err = err
+			m.status = "read failed: " + err.Error()
+			return
+		}
+
+		m.winStart = start
+		m.winLines = m.hl.highlight(lines)
+	}
+
+	m.render()
+}
+
+// render - gutter plus the horizontally scrolled text of the loaded window
+func (m *Model) render() {
+	if m.content.Binary() {
+		m.renderHex()
+		return
+	}
+
+	gutterWidth := len(strconv.Itoa(max(m.src.lineCount(), 1)))
+	textWidth := max(m.width-gutterWidth-3, 1)
+
+	var content strings.Builder
+	for i, line := range m.winLines {
+		number := m.winStart + i
+		gutter := fmt.Sprintf("%*d │ ", gutterWidth, number+1)
+		if number == m.mark {
+			gutter = ui.InfoStyle.Render(gutter)
+		} else {
+			gutter = faintStyle.Render(gutter)
+		}
+
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(gutter + ansi.Cut(line, m.xOffset, m.xOffset+textWidth))
+	}
+
+	m.viewport.SetContent(content.String())
+	m.viewport.SetYOffset(m.top - m.winStart)
+}
+
+// renderHex - hex rows carry their own offset, so there is no gutter
+func (m *Model) renderHex() {
+	var content strings.Builder
+	for i, line := range m.winLines {
+		line = ansi.Cut(line, m.xOffset, m.xOffset+m.width)
+		if m.winStart+i == m.mark {
+			line = ui.InfoStyle.Render(line)
+		}
+
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(line)
+	}
+
+	m.viewport.SetContent(content.String())
+	m.viewport.SetYOffset(m.top - m.winStart)
+}
+
+// View implements tea.Model
+func (m *Model) View() string {
+	if m.width == 0 {
+		return "loading..."
+	}
+
+	// size, MIME type and encoding on the right, the path gets what is left
+	info := fmt.Sprintf(" %s · %s · %s", filesystem.FormatSize(m.content.Size), m.content.MIME, m.content.Encoding)
+	info = ansi.Truncate(info, m.width*2/3, "…")
+	path := ansi.Truncate(m.path, max(m.width-lipgloss.Width(info), 0), "…")
+	gap := strings.Repeat(" ", max(m.width-lipgloss.Width(path)-lipgloss.Width(info), 0))
+	header := ui.HeaderStyle.UnsetPadding().Render(path) + gap + faintStyle.Render(info)
+
+	body := m.viewport.View()
+	if m.src.lineCount() == 0 {
+		body = lipgloss.PlaceVertical(m.height, lipgloss.Top, faintStyle.Render("(empty file)"))
+	}
+
+	return header + "\n" + body + "\n" + m.statusLine()
+}
+
+func (m *Model) statusLine() string {
+	if m.mode != normalMode {
+		return m.input.View()
+	}
+
+	total := m.src.lineCount()
+	last := min(m.top+m.height, total)
+	percent := 100
+	if total > m.height {
+		percent = last * 100 / total
+	}
+
+	position := fmt.Sprintf(" %s  %d-%d/%d  %d%% ", m.hl.name(), min(m.top+1, total), last, total, percent)
+	help := " / search  n/N next  :N jump  ←/→ scroll  q quit "
+	if m.editorFunc != nil {
+		help = " / search  n/N next  :N jump  ←/→ scroll  e edit  q quit "
+	}
+	if m.content.Binary() {
+		help = strings.Replace(help, ":N jump", ":N offset", 1)
+	}
+	if m.status != "" {
+		help = " " + m.status + " "
+	}
+
+	gap := max(m.width-lipgloss.Width(position)-lipgloss.Width(help), 0)
+	return statusStyle.Render(ansi.Truncate(help+strings.Repeat(" ", gap)+position, m.width, ""))
+}
@@ -0,0 +1,442 @@
+package fuzzyfinder
+
+import (
+	"context"
+	"fmt"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/walker"
+	"sort"
+	"strings"
+)
+
+// minPreviewWidth - narrower terminals show the list alone
+const minPreviewWidth = 80
+
+var (
+	faintStyle  = lipgloss.NewStyle().Faint(true)
+	cursorStyle = lipgloss.NewStyle().Reverse(true)
+)
+
+// Output - what was picked, absolute paths in the order they were selected
+type Output struct {
+	Paths []string
+	Quit  bool
+}
+
+// indexMsg - the workspace walk finished
+type indexMsg struct {
+	entries []entry
+	err     error
+}
+
+// entry - a file or directory that can be picked, rel is slash separated and what is matched
+type entry struct {
+	path  string
+	rel   string
+	isDir bool
+}
+
+// candidate - an entry matching the query and how well
+type candidate struct {
+	entry *entry
+	score int
+}
+
+type Model struct {
+	output       *Output
+	root         string
+	header       string
+	validateFunc func(paths []string) (bool, error)
+	multi        bool
+	dirs         bool
+
+	input      textinput.Model
+	entries    []entry
+	candidates []candidate
+	query      string
+	cursor     int
+	offset     int
+	selected   []string // paths in the order they were picked
+	indexing   bool
+
+	preview *preview
+	width   int
+	height  int
+	err     error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// InitialFuzzyFinderModel - pick files below root by typing parts of their path, files left out by
+// .gitignore and .ignore files are not offered. validateFunc checks what was picked before the finder
+// quits, see WithMulti and WithDirs for picking more than one file and directories
+func InitialFuzzyFinderModel(output *Output, root, header string, validateFunc func(paths []string) (bool, error)) *Model {
+	ti := textinput.New()
+	ti.Placeholder = "type to search files..."
+	ti.Prompt = "› "
+	ti.Focus()
+	ti.CharLimit = 256
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Model{
+		output:       output,
+		root:         root,
+		header:       ui.RenderHeader(header),
+		validateFunc: validateFunc,
+		input:        ti,
+		indexing:     true,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// WithMulti - tab marks files, enter picks every marked one
+func (m *Model) WithMulti() *Model {
+	m.multi = true
+	return m
+}
+
+// WithDirs - directories can be picked too
+func (m *Model) WithDirs() *Model {
+	m.dirs = true
+	return m
+}
+
+// Init implements tea.Model
+func (m *Model) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.index)
+}
+
+// index - walk the workspace once, the list fills in when it is done
+func (m *Model) index() tea.Msg {
+	tree, err := walker.Walk(m.ctx, m.root, walker.Options{Ignore: true, IgnoreRoot: m.root})
+	if err != nil {
+		return indexMsg{err: err}
+	}
+
+	var entries []entry
+	for _, node := range tree.Flatten() {
+		if node.Rel == "." || (node.IsDir && !m.dirs) {
+			continue
+		}
+		rel := node.Rel
+		if node.IsDir {
+			rel += "/"
+		}
+		entries = append(entries, entry{path: node.Path, rel: rel, isDir: node.IsDir})
+	}
+
+	// files before directories, then by depth, so plain listings start with what is closest
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].isDir != entries[j].isDir {
+			return !entries[i].isDir
+		}
+		return strings.Count(entries[i].rel, "/") < strings.Count(entries[j].rel, "/")
+	})
+
+	return indexMsg{entries: entries}
+}
+
+// Update implements tea.Model
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = max(m.width-32, 10) // room for the counts
+		m.scroll()
+		return m, nil
+
+	case indexMsg:
+		m.indexing = false
+		if msg.err != nil {
+			m.err = fmt.Errorf("error indexing files: %w", msg.err)
+			return m, nil
+		}
+		m.entries = msg.entries
+		m.rank(true)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.cancel()
+			m.output.Quit = true
+			return m, tea.Quit
+		case "enter":
+			return m.confirm()
+		case "down", "ctrl+n", "ctrl+j":
+			m.move(1)
+			return m, nil
+		case "up", "ctrl+p", "ctrl+k":
+			m.move(-1)
+			return m, nil
+		case "pgdown":
+			m.move(m.listHeight())
+			return m, nil
+		case "pgup":
+			m.move(-m.listHeight())
+			return m, nil
+		case "tab":
+			if m.multi {
+				m.toggle()
+				m.move(1)
+			}
+			return m, nil
+		case "shift+tab":
+			if m.multi {
+				m.toggle()
+				m.move(-1)
+			}
+			return m, nil
+		case "ctrl+a":
+			// marks every match, without multi select it moves to the start of the input
+			if m.multi {
+				for _, c := range m.candidates {
+					if !m.isSelected(c.entry.path) {
+						m.selected = append(m.selected, c.entry.path)
+					}
+				}
+				return m, nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != m.query {
+		m.rank(false)
+	}
+	return m, cmd
+}
+
+// confirm - the marked paths, or the one under the cursor when none are marked
+func (m *Model) confirm() (tea.Model, tea.Cmd) {
+	paths := append([]string(nil), m.selected...)
+	if len(paths) == 0 {
+		if m.cursor >= len(m.candidates) {
+			m.err = fmt.Errorf("no file matches %q", m.input.Value())
+			return m, nil
+		}
+		paths = []string{m.candidates[m.cursor].entry.path}
+	}
+
+	if m.validateFunc != nil {
+		if valid, err := m.validateFunc(paths); !valid {
+			m.err = err
+			return m, nil
+		}
+	}
+
+	m.cancel()
+	m.output.Paths = paths
+	return m, tea.Quit
+}
+
+// rank - order the entries by how well they match the query. a longer query only narrows the
+// previous matches down, so typing stays quick in large workspaces
+func (m *Model) rank(all bool) {
+	query := m.input.Value()
+	p := newPattern(query)
+
+	pool := m.candidates
+	if all || !strings.HasPrefix(query, m.query) || strings.TrimSpace(m.query) == "" {
+		pool = make([]candidate, len(m.entries))
+		for i := range m.entries {
+			pool[i] = candidate{entry: &m.entries[i]}
+		}
+	}
+	m.query = query
+
+	if p.empty() {
+		m.candidates = pool
+	} else {
+		candidates := make([]candidate, 0, len(pool))
+		for _, c := range pool {
+			if score, _, ok := p.match(c.entry.rel, false); ok {
+				candidates = append(candidates, candidate{entry: c.entry, score: score})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if a.score != b.score {
+				return a.score > b.score
+			}
+			return len(a.entry.rel) < len(b.entry.rel)
+		})
+		m.candidates = candidates
+	}
+
+	m.cursor, m.offset = 0, 0
+	m.err = nil
+}
+
+func (m *Model) move(delta int) {
+	if len(m.candidates) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.candidates)-1)
+	m.scroll()
+}
+
+// scroll - keep the cursor inside the visible part of the list
+func (m *Model) scroll() {
+	height := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+}
+
+func (m *Model) toggle() {
+	if m.cursor >= len(m.candidates) {
+		return
+	}
+	path := m.candidates[m.cursor].entry.path
+	for i, selected := range m.selected {
+		if selected == path {
+			m.selected = append(m.selected[:i], m.selected[i+1:]...)
+			return
+		}
+	}
+	m.selected = append(m.selected, path)
+}
+
+func (m *Model) isSelected(path string) bool {
+	for _, selected := range m.selected {
+		if selected == path {
+			return true
+		}
+	}
+	return false
+}
+
+// listHeight - rows left for the list below the header and input, above the help and error lines
+func (m *Model) listHeight() int {
+	return max(m.height-lipgloss.Height(m.header)-4, 3)
+}
+
+// View implements tea.Model
+func (m *Model) View() string {
+	var s strings.Builder
+	s.WriteString(m.header + "\n")
+
+	count := fmt.Sprintf("%d/%d", len(m.candidates), len(m.entries))
+	if m.indexing {
+		count = "indexing..."
+	}
+	if m.multi && len(m.selected) > 0 {
+		count += fmt.Sprintf(" (%d selected)", len(m.selected))
+	}
+	s.WriteString(m.input.View() + "  " + faintStyle.Render(count) + "\n")
+
+	listWidth, previewWidth := m.width, 0
+	if m.width >= minPreviewWidth {
+		listWidth = m.width * 2 / 5
+		previewWidth = m.width - listWidth - 3
+	}
+
+	list := m.renderList(listWidth)
+	if previewWidth > 0 {
+		divider := strings.TrimSuffix(strings.Repeat(faintStyle.Render(" │ ")+"\n", m.listHeight()), "\n")
+		list = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(listWidth).Render(list),
+			divider,
+			m.renderPreview(previewWidth),
+		)
+	}
+	s.WriteString(list + "\n")
+
+	if m.err != nil {
+		s.WriteString(ui.ErrorStyle.UnsetMargins().Render(m.err.Error()) + "\n")
+	}
+
+	help := "↑/↓ move  enter pick  esc quit"
+	if m.multi {
+		help = "↑/↓ move  tab mark  ctrl+a mark all  enter pick  esc quit"
+	}
+	s.WriteString(ui.RenderInfo(help))
+
+	return s.String()
+}
+
+// renderList - the visible candidates, matched characters highlighted
+func (m *Model) renderList(width int) string {
+	height := m.listHeight()
+	p := newPattern(m.query)
+
+	lines := make([]string, 0, height)
+	for i := m.offset; i < len(m.candidates) && len(lines) < height; i++ {
+		e := m.candidates[i].entry
+
+		marker := "  "
+		if m.isSelected(e.path) {
+			marker = ui.SuccessStyle2.Render("● ")
+		}
+
+		// long paths keep their end, where the file name is
+		rel := e.rel
+		if room := max(width-3, 1); ansi.StringWidth(rel) > room {
+			rel = "…" + ansi.TruncateLeft(rel, ansi.StringWidth(rel)-room+1, "")
+		}
+
+		line := highlight(rel, p)
+		if i == m.cursor {
+			line = cursorStyle.Render(rel)
+		}
+		lines = append(lines, marker+line)
+	}
+
+	if len(lines) == 0 && !m.indexing {
+		lines = append(lines, faintStyle.Render("  no matches"))
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlight - text with the characters the pattern matched styled
+func highlight(text string, p *pattern) string {
+	_, positions, ok := p.match(text, true)
+	if !ok || len(positions) == 0 {
+		return text
+	}
+
+	matched := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		matched[position] = true
+	}
+
+	// the theme is applied after start up, so the style is taken when rendering
+	matchStyle := ui.InfoStyle.Bold(true).Italic(false)
+
+	var s strings.Builder
+	for i, r := range []rune(text) {
+		if matched[i] {
+			s.WriteString(matchStyle.Render(string(r)))
+		} else {
+			s.WriteRune(r)
+		}
+	}
+	return s.String()
+}
+
+func (m *Model) renderPreview(width int) string {
+	if m.cursor >= len(m.candidates) {
+		return ""
+	}
+
+	e := m.candidates[m.cursor].entry
+	if m.preview == nil || m.preview.path != e.path {
+		m.preview = loadPreview(e.path, e.isDir, m.listHeight())
+	}
+	return m.preview.render(width, m.listHeight())
+}
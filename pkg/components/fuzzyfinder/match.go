@@ -0,0 +1,193 @@
+package fuzzyfinder
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scores of a match, in the spirit of fzf: every matched character counts, more so at the start of
+// a path segment or word and right after the previous one, gaps between matched characters cost
+const (
+	scoreMatch       = 16
+	bonusSegment     = 10 // after a slash or at the start
+	bonusBoundary    = 8  // after _ - . or a space
+	bonusCamel       = 7  // upper case after lower case
+	bonusConsecutive = 6
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+)
+
+// noScore - a character that cannot be matched at a position
+const noScore = -1 << 30
+
+// pattern - a query ready to be matched, case is ignored unless it has upper case characters.
+// it keeps scratch space between matches, so it is not safe for concurrent use
+type pattern struct {
+	runes     []rune
+	sensitive bool
+
+	text   []rune
+	folded []rune
+	bonus  []int
+	rows   [2][]int
+}
+
+func newPattern(query string) *pattern {
+	query = strings.TrimSpace(query)
+	return &pattern{
+		runes:     []rune(query),
+		sensitive: strings.ToLower(query) != query,
+	}
+}
+
+func (p *pattern) empty() bool {
+	return len(p.runes) == 0
+}
+
+// match - the score of the best alignment of the pattern in text and the positions of its
+// characters, false when text does not hold the pattern in order. positions is only filled
+// when asked for, ranking every file does not need them
+func (p *pattern) match(text string, withPositions bool) (int, []int, bool) {
+	if p.empty() {
+		return 0, nil, true
+	}
+
+	p.text = append(p.text[:0], []rune(text)...)
+	p.folded = append(p.folded[:0], p.text...)
+	if !p.sensitive {
+		for j, r := range p.folded {
+			p.folded[j] = unicode.ToLower(r)
+		}
+	}
+	if !p.subsequence() {
+		return 0, nil, false
+	}
+
+	m := len(p.text)
+	p.bonus = p.bonus[:0]
+	for j := range p.text {
+		p.bonus = append(p.bonus, bonusAt(p.text, j))
+	}
+
+	if !withPositions {
+		for k := range p.rows {
+			p.rows[k] = grow(p.rows[k], m)
+		}
+		prev, cur := p.rows[0], p.rows[1]
+		for i := range p.runes {
+			p.scoreRow(i, prev, cur)
+			prev, cur = cur, prev
+		}
+		total := best(prev)
+		return total, nil, total > noScore/2
+	}
+
+	// score[i][j] - best score with pattern[:i+1] matched and pattern[i] at text[j]
+	n := len(p.runes)
+	score := make([][]int, n)
+	for i := range score {
+		score[i] = make([]int, m)
+		var prev []int
+		if i > 0 {
+			prev = score[i-1]
+		}
+		p.scoreRow(i, prev, score[i])
+	}
+
+	total := best(score[n-1])
+	if total <= noScore/2 {
+		return 0, nil, false
+	}
+
+	// walk back through the alignment that gave the best score
+	positions := make([]int, n)
+	for j, s := range score[n-1] {
+		if s == total {
+			positions[n-1] = j
+			break
+		}
+	}
+	for i := n - 1; i > 0; i-- {
+		j := positions[i]
+		want := score[i][j] - scoreMatch - p.bonus[j]
+		from := j - 1
+		if j == 0 || score[i-1][j-1] <= noScore/2 || score[i-1][j-1]+bonusConsecutive != want {
+			for k := j - 2; k >= 0; k-- {
+				if score[i-1][k] > noScore/2 && score[i-1][k]-penaltyGapStart-(j-k-2)*penaltyGapExtend == want {
+					from = k
+					break
+				}
+			}
+		}
+		positions[i-1] = from
+	}
+	return total, positions, true
+}
+
+// scoreRow - scores of pattern[i] at every position of the text given the row of pattern[i-1]
+func (p *pattern) scoreRow(i int, prev, row []int) {
+	gap := noScore // best prev[k] less the gap to j, for k < j-1
+	for j := range row {
+		row[j] = noScore
+		if i > 0 && j >= 2 {
+			gap = max(gap-penaltyGapExtend, prev[j-2]-penaltyGapStart)
+		}
+		if p.folded[j] != p.runes[i] {
+			continue
+		}
+
+		if i == 0 {
+			row[j] = scoreMatch + p.bonus[j]
+			continue
+		}
+		from := gap
+		if j > 0 && prev[j-1] > noScore/2 {
+			from = max(from, prev[j-1]+bonusConsecutive)
+		}
+		if from > noScore/2 {
+			row[j] = from + scoreMatch + p.bonus[j]
+		}
+	}
+}
+
+// subsequence - quick check that every pattern character is in the text in order
+func (p *pattern) subsequence() bool {
+	i := 0
+	for _, r := range p.folded {
+		if i < len(p.runes) && r == p.runes[i] {
+			i++
+		}
+	}
+	return i == len(p.runes)
+}
+
+func best(row []int) int {
+	total := noScore
+	for _, s := range row {
+		total = max(total, s)
+	}
+	return total
+}
+
+func grow(row []int, n int) []int {
+	if cap(row) < n {
+		return make([]int, n)
+	}
+	return row[:n]
+}
+
+func bonusAt(text []rune, j int) int {
+	if j == 0 {
+		return bonusSegment
+	}
+	prev, r := text[j-1], text[j]
+	switch {
+	case prev == '/':
+		return bonusSegment
+	case prev == '_' || prev == '-' || prev == '.' || prev == ' ':
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(r):
+		return bonusCamel
+	}
+	return 0
+}
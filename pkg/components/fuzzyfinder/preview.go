@@ -0,0 +1,135 @@
+package fuzzyfinder
+
+import (
+	"fmt"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxPreviewBytes - only the start of a file is read for its preview
+const maxPreviewBytes = 64 * 1024
+
+// preview - the first lines of the file under the cursor, or what a directory holds
+type preview struct {
+	path  string
+	title string
+	lines []string
+	err   error
+}
+
+func loadPreview(path string, isDir bool, height int) *preview {
+	p := &preview{path: path}
+	if isDir {
+		p.lines, p.err = dirPreview(path, height)
+		return p
+	}
+
+	content, err := filesystem.Sniff(path)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	p.title = fmt.Sprintf("%s, %s", filesystem.FormatSize(content.Size), content.Encoding)
+	if content.Binary() {
+		p.title = fmt.Sprintf("binary file, %s, %s", filesystem.FormatSize(content.Size), content.MIME)
+		return p
+	}
+
+	text, err := previewText(path, content)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	if text == "" {
+		return p
+	}
+	for i, line := range strings.SplitN(text, "\n", height+1) {
+		if i == height {
+			break
+		}
+		line = strings.TrimSuffix(line, "\r")
+		line = strings.ReplaceAll(ansi.Strip(line), "\t", "    ")
+		p.lines = append(p.lines, line)
+	}
+	return p
+}
+
+// previewText - the start of a text file, UTF-16 is decoded when the file is small enough to read whole
+func previewText(path string, content filesystem.Content) (string, error) {
+	if content.Encoding == filesystem.EncodingUTF16LE || content.Encoding == filesystem.EncodingUTF16BE {
+		if content.Size > maxPreviewBytes {
+			return "", fmt.Errorf("UTF-16 file too large to preview")
+		}
+		text, _, err := filesystem.ReadText(path)
+		return text, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxPreviewBytes))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(string(data), "\ufeff"), nil
+}
+
+// dirPreview - names in a directory, directories marked with a slash
+func dirPreview(path string, height int) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, min(len(entries), height))
+	for _, entry := range entries {
+		if len(lines) == height {
+			break
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		lines = append(lines, name)
+	}
+	if len(entries) == 0 {
+		lines = append(lines, "empty directory")
+	}
+	return lines, nil
+}
+
+// render - the preview cut to width and height, line numbers for files
+func (p *preview) render(width, height int) string {
+	var lines []string
+	if p.title != "" {
+		lines = append(lines, faintStyle.Render(ansi.Truncate(p.title, width, "…")))
+		height--
+	}
+	if p.err != nil {
+		lines = append(lines, ui.ErrorStyle.UnsetMargins().Render(ansi.Truncate(p.err.Error(), width, "…")))
+		return strings.Join(lines, "\n")
+	}
+
+	numbered := p.title != ""
+	for i, line := range p.lines {
+		if i == height {
+			break
+		}
+		if numbered {
+			line = faintStyle.Render(fmt.Sprintf("%4d ", i+1)) + ansi.Truncate(line, max(width-5, 0), "…")
+		} else {
+			line = ansi.Truncate(line, width, "…")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
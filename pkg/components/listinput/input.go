@@ -4,6 +4,7 @@ import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"os/exec"
 )
 
 var (
@@ -33,6 +34,12 @@ type Model struct {
 	choice       *Selection
 	header       string
 	validateFunc func(string, string) (string, bool, error)
+	execFunc     func(string, string) (*exec.Cmd, error)
+}
+
+// execDoneMsg - the program started for a choice exited
+type execDoneMsg struct {
+	err error
 }
 
 func (m Model) Init() tea.Cmd {
@@ -51,8 +58,21 @@ func InitialListInputModel(choices []string, fileName string, selection *Selecti
 	}
 }
 
+// WithExec - program to run in the terminal for a valid choice, the list is suspended while it runs
+// and quits once it exits. a nil command runs nothing
+func (m Model) WithExec(execFunc func(fileName, choice string) (*exec.Cmd, error)) Model {
+	m.execFunc = execFunc
+	return m
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case execDoneMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		return m, tea.Quit
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "up", "k":
@@ -67,6 +87,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			//validate options
 			result, valid, m.err = m.validateFunc(m.fileName, m.choices[m.cursor])
 			if valid {
+				m.choice.Update(m.choices[m.cursor])
 				if len(m.selected) == 1 {
 					m.selected = make(map[int]struct{})
 				}
@@ -78,6 +99,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selected[m.cursor] = struct{}{}
 				}
 
+				if m.execFunc != nil {
+					cmd, err := m.execFunc(m.fileName, m.choices[m.cursor])
+					if err != nil {
+						m.err = err
+						return m, nil
+					}
+					if cmd != nil {
+						return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+							return execDoneMsg{err: err}
+						})
+					}
+				}
+
 				return m, tea.Quit
 			}
 
@@ -87,7 +121,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(m.selected) == 1 {
 				return m, tea.Quit
 			}
-		case "esc":
+		case "esc", "ctrl+c":
+			m.choice.QuitCmd()
 			return m, tea.Quit
 		}
 	}
@@ -116,7 +151,7 @@ func (m Model) View() string {
 	view += fmt.Sprintf("\n%s\n", ui.RenderInfo("(press enter to confirm choice, esc to quit)"))
 
 	// show code if default is selected
-	if _, ok := m.selected[m.cursor]; ok && m.cursor == 0 {
+	if _, ok := m.selected[m.cursor]; ok && m.cursor == 0 && result != "" {
 		view += fmt.Sprintf("\n%s\n", ui.RenderCode(fmt.Sprintf("\n%+v\n", result)))
 	}
 
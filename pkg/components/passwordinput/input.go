@@ -1,12 +1,13 @@
 package passwordinput
 
-import "C"
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/auth"
 	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
-	"os"
 	"os/user"
 	"strings"
 )
@@ -19,6 +20,7 @@ var (
 type deleteResult struct {
 	err     error
 	message string
+	retry   bool // wrong password, ask again
 }
 
 // state
@@ -37,14 +39,18 @@ type Model struct {
 	state      state
 	targetPath string
 	isDir      bool
+	permanent  bool
+	verifier   auth.Verifier
+	deleteFunc func(string) (string, error)
 	textInput  textinput.Model
 	password   string
 	err        error
 	message    string
 }
 
-// InitialPasswordInputModel - initialize the model
-func InitialPasswordInputModel(path string, isDirectory bool) Model {
+// InitialPasswordInputModel - initialize the model, the password is checked with verifier
+// and deleteFunc performs the deletion once it is accepted, permanent only changes the wording
+func InitialPasswordInputModel(path string, isDirectory, permanent bool, verifier auth.Verifier, deleteFunc func(string) (string, error)) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Enter your password"
 	ti.EchoMode = textinput.EchoPassword
@@ -55,10 +61,26 @@ func InitialPasswordInputModel(path string, isDirectory bool) Model {
 		state:      confirmationState,
 		targetPath: path,
 		isDir:      isDirectory,
+		permanent:  permanent,
+		verifier:   verifier,
+		deleteFunc: deleteFunc,
 		textInput:  ti,
 	}
 }
 
+// Cancelled - the user declined or quit before the path was deleted
+func (m Model) Cancelled() bool {
+	return m.state != completedState && m.state != errorState
+}
+
+// Err - error raised while authenticating or deleting, if any
+func (m Model) Err() error {
+	if m.state == errorState {
+		return m.err
+	}
+	return nil
+}
+
 // Init implements tea.Model
 func (m Model) Init() tea.Cmd {
 	return textinput.Blink
@@ -97,7 +119,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Authenticate and delete
 				m.state = processingState
 				return m, tea.Batch(
-					func() tea.Msg { return authenticateAndDelete(m.targetPath, m.password) },
+					func() tea.Msg { return authenticateAndDelete(m.targetPath, m.password, m.verifier, m.deleteFunc) },
 				)
 			case "ctrl+c":
 				return m, tea.Quit
@@ -119,6 +141,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case deleteResult:
+		if msg.retry {
+			m.state = passwordState
+			m.err = msg.err
+			m.textInput.SetValue("")
+			return m, textinput.Blink
+		}
 		if msg.err != nil {
 			m.state = errorState
 			m.err = msg.err
@@ -147,7 +175,11 @@ func (m Model) View() string {
 	case confirmationState:
 		if m.isDir {
 			s.WriteString(ui.RenderInfo("warning: This is a directory!") + "\n")
-			s.WriteString("all contents will be permanently deleted.\n\n")
+		}
+		if m.permanent {
+			s.WriteString("it will be permanently deleted, this cannot be undone.\n\n")
+		} else {
+			s.WriteString("it will be moved to the trash, restore it with `agent-code trash restore`.\n\n")
 		}
 
 		s.WriteString("are you sure you want to delete this " + itemType + "?" + "\n\n")
@@ -194,47 +226,34 @@ func (m Model) View() string {
 }
 
 // authenticate and delete function
-func authenticateAndDelete(path, password string) deleteResult {
-	// verify password (simplified for demo)
-	if err := verifyPassword(password); err != nil {
-		return deleteResult{err: fmt.Errorf("authentication failed: %w", err)}
+func authenticateAndDelete(path, password string, verifier auth.Verifier, deleteFunc func(string) (string, error)) deleteResult {
+	// verify the current user's system password
+	if err := verifyPassword(verifier, password); err != nil {
+		return deleteResult{
+			err:   fmt.Errorf("authentication failed: %w", err),
+			retry: errors.Is(err, auth.ErrInvalidCredentials) || errors.Is(err, auth.ErrEmptyPassword),
+		}
 	}
 
 	// perform deletion
-	info, err := os.Stat(path)
+	message, err := deleteFunc(path)
 	if err != nil {
-		return deleteResult{err: fmt.Errorf("error accessing path: %w", err)}
+		return deleteResult{err: err}
 	}
 
-	if info.IsDir() {
-		err = os.RemoveAll(path)
-	} else {
-		err = os.Remove(path)
-	}
-
-	if err != nil {
-		return deleteResult{err: fmt.Errorf("deletion failed: %w", err)}
-	}
-
-	itemType := "file"
-	if info.IsDir() {
-		itemType = "directory"
-	}
-
-	return deleteResult{message: fmt.Sprintf("Successfully deleted %s: %s", itemType, path)}
+	return deleteResult{message: message}
 }
 
-func verifyPassword(password string) error {
+func verifyPassword(verifier auth.Verifier, password string) error {
 	if len(password) == 0 {
-		return fmt.Errorf("password cannot be empty")
+		return auth.ErrEmptyPassword
 	}
 
 	// get current user
-	_, err := user.Current()
+	username, err := auth.CurrentUsername()
 	if err != nil {
-		return fmt.Errorf("unable to get current user: %w", err)
+		return err
 	}
 
-	// authenticate using system-specific auth (to work on)
-	return nil
+	return verifier.Verify(context.Background(), username, password)
 }
@@ -0,0 +1,216 @@
+package runview
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/executor"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"strings"
+	"time"
+)
+
+// defaultVisibleLines - output lines kept on screen before the window size is known
+const defaultVisibleLines = 20
+
+// OutputMsg - live output from the running command
+type OutputMsg struct {
+	Text   string
+	Stderr bool
+}
+
+// DoneMsg - the command finished
+type DoneMsg struct {
+	Result *executor.Result
+	Err    error
+}
+
+type state int
+
+const (
+	confirmState state = iota
+	runningState
+	doneState
+	cancelledState
+)
+
+type line struct {
+	text   string
+	stderr bool
+}
+
+var faintStyle = lipgloss.NewStyle().Faint(true)
+
+type Model struct {
+	state    state
+	command  string
+	lines    []line
+	partial  map[bool]string
+	height   int
+	result   *executor.Result
+	err      error
+	approval chan<- bool
+	cancel   context.CancelFunc
+}
+
+// InitialRunViewModel - live view of a command. when approval is not nil the user is asked
+// to confirm first and the answer is sent on it, cancel stops the command when the user quits
+func InitialRunViewModel(command string, approval chan<- bool, cancel context.CancelFunc) *Model {
+	m := &Model{
+		state:    runningState,
+		command:  command,
+		partial:  make(map[bool]string),
+		approval: approval,
+		cancel:   cancel,
+	}
+	if approval != nil {
+		m.state = confirmState
+	}
+	return m
+}
+
+// Cancelled - the user declined or stopped the command
+func (m *Model) Cancelled() bool {
+	return m.state == cancelledState
+}
+
+// Result - result of the finished command
+func (m *Model) Result() (*executor.Result, error) {
+	return m.result, m.err
+}
+
+// Init implements tea.Model
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch m.state {
+		case confirmState:
+			switch msg.String() {
+			case "y", "Y":
+				m.state = runningState
+				m.approval <- true
+			case "n", "N", "esc", "ctrl+c":
+				m.state = cancelledState
+				m.approval <- false
+				return m, tea.Quit
+			}
+		case runningState:
+			switch msg.String() {
+			case "ctrl+c", "esc":
+				m.state = cancelledState
+				if m.cancel != nil {
+					m.cancel()
+				}
+				return m, tea.Quit
+			}
+		}
+
+	case OutputMsg:
+		m.addOutput(msg.Text, msg.Stderr)
+
+	case DoneMsg:
+		// flush lines without a trailing newline
+		for _, stderr := range []bool{false, true} {
+			if rest := m.partial[stderr]; rest != "" {
+				m.lines = append(m.lines, line{text: rest, stderr: stderr})
+				m.partial[stderr] = ""
+			}
+		}
+
+		if m.state != cancelledState {
+			m.state = doneState
+		}
+		m.result = msg.Result
+		m.err = msg.Err
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+func (m *Model) addOutput(text string, stderr bool) {
+	text = m.partial[stderr] + text
+	parts := strings.Split(text, "\n")
+
+	m.partial[stderr] = parts[len(parts)-1]
+	for _, part := range parts[:len(parts)-1] {
+		m.lines = append(m.lines, line{text: strings.TrimRight(part, "\r"), stderr: stderr})
+	}
+}
+
+// View implements tea.Model
+func (m *Model) View() string {
+	var s strings.Builder
+	s.WriteString(ui.RenderHeader("Run") + "\n")
+	s.WriteString(ui.RenderCode("$ "+m.command) + "\n\n")
+
+	if m.state == confirmState {
+		s.WriteString(ui.RenderInfo("this command is not pre-approved. press [Y] to run it, [N] to cancel") + "\n")
+		return s.String()
+	}
+
+	// only the tail of the output is shown while running, everything once done
+	lines := m.lines
+	if m.state == runningState {
+		visible := defaultVisibleLines
+		if m.height > 8 {
+			visible = m.height - 8
+		}
+		if len(lines) > visible {
+			lines = lines[len(lines)-visible:]
+		}
+	}
+
+	for _, l := range lines {
+		if l.stderr {
+			// built on render so the configured theme applies
+			s.WriteString(ui.ErrorStyle.UnsetMargins().UnsetBold().Render(l.text) + "\n")
+		} else {
+			s.WriteString(ui.TextStyle.Render(l.text) + "\n")
+		}
+	}
+
+	switch m.state {
+	case runningState:
+		s.WriteString("\n" + ui.RenderInfo("running... (press esc/ctrl+c to stop)") + "\n")
+	case cancelledState:
+		s.WriteString(ui.RenderError("cancelled") + "\n")
+	case doneState:
+		s.WriteString(RenderStatus(m.result, m.err) + "\n")
+	}
+
+	return s.String()
+}
+
+// RenderStatus - exit status line of a finished command
+func RenderStatus(result *executor.Result, err error) string {
+	if result == nil {
+		if err != nil {
+			return ui.RenderError(err.Error())
+		}
+		return ""
+	}
+
+	details := fmt.Sprintf("exit code %d in %s", result.ExitCode, result.Duration.Round(time.Millisecond))
+	if result.Truncated {
+		details += ", captured output truncated"
+	}
+
+	switch {
+	case err != nil:
+		return ui.RenderError(err.Error()) + faintStyle.Render(details)
+	case result.ExitCode != 0:
+		return ui.RenderError(details)
+	default:
+		return ui.RenderSuccess(details)
+	}
+}
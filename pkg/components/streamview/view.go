@@ -0,0 +1,120 @@
+package streamview
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/ui"
+	"strings"
+)
+
+// ChunkMsg - streamed text to append to the view
+type ChunkMsg string
+
+// DoneMsg - stream finished, Err is set when it failed
+type DoneMsg struct {
+	Usage provider.Usage
+	Err   error
+}
+
+type Model struct {
+	header    string
+	content   strings.Builder
+	width     int
+	done      bool
+	cancelled bool
+	usage     provider.Usage
+	err       error
+	cancel    context.CancelFunc
+}
+
+// InitialStreamViewModel - view that renders text as it streams in,
+// cancel is called when the user quits before the stream is done
+func InitialStreamViewModel(header string, cancel context.CancelFunc) *Model {
+	return &Model{
+		header: ui.RenderHeader(header),
+		cancel: cancel,
+	}
+}
+
+// Content - text received so far
+func (m *Model) Content() string {
+	return m.content.String()
+}
+
+// Cancelled - user quit before the stream was done
+func (m *Model) Cancelled() bool {
+	return m.cancelled
+}
+
+// Err - error that ended the stream, if any
+func (m *Model) Err() error {
+	return m.err
+}
+
+// Init implements tea.Model
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			if !m.done {
+				m.cancelled = true
+				if m.cancel != nil {
+					m.cancel()
+				}
+			}
+			return m, tea.Quit
+		}
+
+	case ChunkMsg:
+		m.content.WriteString(string(msg))
+
+	case DoneMsg:
+		m.done = true
+		m.usage = msg.Usage
+		if msg.Err != nil && !m.cancelled {
+			m.err = msg.Err
+		}
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// View implements tea.Model
+func (m *Model) View() string {
+	var s strings.Builder
+	s.WriteString(m.header + "\n")
+
+	text := ui.TextStyle
+	if m.width > 0 {
+		text = text.Width(m.width - 1)
+	}
+	s.WriteString(text.Render(m.content.String()) + "\n\n")
+
+	switch {
+	case m.err != nil:
+		s.WriteString(ui.RenderError(m.err.Error()) + "\n")
+	case m.cancelled:
+		s.WriteString(ui.RenderError("cancelled") + "\n")
+	case m.done:
+		if m.usage.TotalTokens > 0 {
+			s.WriteString(lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("tokens: %d prompt, %d completion", m.usage.PromptTokens, m.usage.CompletionTokens)) + "\n")
+		}
+	default:
+		s.WriteString(ui.RenderInfo("streaming... (press esc/ctrl+c to stop)") + "\n")
+	}
+
+	return s.String()
+}
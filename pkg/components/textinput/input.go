@@ -81,6 +81,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case tea.KeyCtrlC, tea.KeyEsc:
+			m.output.QuitCmd()
 			return m, tea.Quit
 		}
 
@@ -0,0 +1,353 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/executor"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/trash"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ProjectFile - project config, read from the workspace root
+const ProjectFile = ".agent-code.yaml"
+
+// UserOnlyKeys - settings a project file cannot set, a checkout is not trusted with the model
+// endpoint and key, the commands that run without confirmation or the editors that are started.
+// a key ending in a section name covers every key of the section
+var UserOnlyKeys = []string{"model", "run.allow", "run.deny", "open.editors"}
+
+// ErrUserOnlyKey - a project file sets one of UserOnlyKeys
+var ErrUserOnlyKey = errors.New("can only be set in the user config")
+
+// environment variables read on top of the config files
+const (
+	ExtensionsEnv = "AGENT_CODE_EXTENSIONS"
+	EditorsEnv    = "AGENT_CODE_EDITORS"
+	ThemeEnv      = "AGENT_CODE_THEME"
+)
+
+// Config - every setting, layered lowest first: defaults, user file, project file, env vars.
+// flags are applied on top by the commands
+type Config struct {
+	Workspace WorkspaceConfig `yaml:"workspace,omitempty"`
+	Create    CreateConfig    `yaml:"create,omitempty"`
+	Open      OpenConfig      `yaml:"open,omitempty"`
+	Model     ModelConfig     `yaml:"model,omitempty"`
+	Run       RunConfig       `yaml:"run,omitempty"`
+	Trash     TrashConfig     `yaml:"trash,omitempty"`
+	UI        UIConfig        `yaml:"ui,omitempty"`
+
+	// Files - config files that were found and merged, lowest precedence first
+	Files []string `yaml:"-"`
+}
+
+// WorkspaceConfig - sandbox every file command is confined to
+type WorkspaceConfig struct {
+	Root string `yaml:"root,omitempty"`
+}
+
+// CreateConfig - settings of the create command
+type CreateConfig struct {
+	Extensions []string `yaml:"extensions,omitempty"`
+}
+
+// OpenConfig - settings of the open command
+type OpenConfig struct {
+	Editors []string `yaml:"editors,omitempty"`
+}
+
+// ModelConfig - chat completion provider settings
+type ModelConfig struct {
+	Provider string        `yaml:"provider,omitempty"`
+	BaseURL  string        `yaml:"base_url,omitempty"`
+	Name     string        `yaml:"name,omitempty"`
+	APIKey   string        `yaml:"api_key,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty"`
+}
+
+// RunConfig - command execution policy
+type RunConfig struct {
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	Allow   []string      `yaml:"allow,omitempty"`
+	Deny    []string      `yaml:"deny,omitempty"`
+}
+
+// TrashConfig - where deleted files go, workspace or home
+type TrashConfig struct {
+	Location string `yaml:"location,omitempty"`
+}
+
+// UIConfig - terminal styling
+type UIConfig struct {
+	Theme string `yaml:"theme,omitempty"`
+}
+
+// Options - where to load from, the empty values fall back to the defaults
+type Options struct {
+	// File - user config file, replaces the one in $XDG_CONFIG_HOME and must exist
+	File string
+
+	// Root - workspace root from the --root flag
+	Root string
+}
+
+// Default - built in settings
+func Default() *Config {
+	return &Config{
+		Create: CreateConfig{Extensions: []string{".go", ".js", ".py", ".php"}},
+		Open:   OpenConfig{Editors: []string{"Default", "Code"}},
+		Model: ModelConfig{
+			Provider: provider.NameOpenAI,
+			BaseURL:  provider.DefaultBaseURL,
+			Name:     provider.DefaultModel,
+			Timeout:  provider.DefaultTimeout,
+		},
+		Run: RunConfig{
+			Timeout: executor.DefaultTimeout,
+			Allow:   append([]string(nil), executor.DefaultAllow...),
+			Deny:    append([]string(nil), executor.DefaultDeny...),
+		},
+		Trash: TrashConfig{Location: "workspace"},
+		UI:    UIConfig{Theme: "default"},
+	}
+}
+
+// UserPath - $XDG_CONFIG_HOME/agent-code/config.yaml, defaulting to ~/.config
+func UserPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	return filepath.Join(dir, "agent-code", "config.yaml"), nil
+}
+
+// ProjectPath - project config file of a workspace root
+func ProjectPath(root string) string {
+	return filepath.Join(root, ProjectFile)
+}
+
+// Load - merge every layer. the workspace root is detected after the user file so it can set it,
+// the project file is then read from that root and cannot move it.
+// Workspace.Root of the result is always the detected absolute root
+func Load(opts Options) (*Config, error) {
+	cfg := Default()
+
+	// OPENAI_* variables are ambient defaults, any config file overrides them
+	cfg.applyEnv(map[*string]string{
+		&cfg.Model.BaseURL: "OPENAI_BASE_URL",
+		&cfg.Model.Name:    "OPENAI_MODEL",
+		&cfg.Model.APIKey:  "OPENAI_API_KEY",
+	})
+
+	userPath := opts.File
+	if userPath == "" {
+		path, err := UserPath()
+		if err != nil {
+			return nil, err
+		}
+		userPath = path
+	}
+
+	if err := cfg.merge(userPath, opts.File != "", false); err != nil {
+		return nil, err
+	}
+
+	root := opts.Root
+	if root == "" && os.Getenv(workspace.RootEnv) == "" {
+		root = cfg.Workspace.Root
+	}
+
+	ws, err := workspace.Detect(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.merge(ProjectPath(ws.Root), false, true); err != nil {
+		return nil, err
+	}
+	cfg.Workspace.Root = ws.Root
+
+	cfg.applyEnv(map[*string]string{
+		&cfg.Model.Provider: provider.ProviderEnv,
+		&cfg.Model.BaseURL:  provider.BaseURLEnv,
+		&cfg.Model.Name:     provider.ModelEnv,
+		&cfg.Model.APIKey:   provider.APIKeyEnv,
+		&cfg.Trash.Location: trash.LocationEnv,
+		&cfg.UI.Theme:       ThemeEnv,
+	})
+	cfg.applyListEnv(map[*[]string]string{
+		&cfg.Create.Extensions: ExtensionsEnv,
+		&cfg.Open.Editors:      EditorsEnv,
+	})
+
+	return cfg, nil
+}
+
+// Provider - provider config of the model settings
+func (c *Config) Provider() provider.Config {
+	return provider.Config{
+		Provider: c.Model.Provider,
+		BaseURL:  c.Model.BaseURL,
+		Model:    c.Model.Name,
+		APIKey:   c.Model.APIKey,
+		Timeout:  c.Model.Timeout,
+	}
+}
+
+// Policy - command policy of the run settings
+func (c *Config) Policy() executor.Policy {
+	return executor.Policy{Allow: c.Run.Allow, Deny: c.Run.Deny}
+}
+
+// YAML - the settings as YAML, the API key is masked unless reveal is set
+func (c *Config) YAML(reveal bool) ([]byte, error) {
+	out := *c
+	if !reveal && out.Model.APIKey != "" {
+		out.Model.APIKey = mask(out.Model.APIKey)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&out); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// merge - decode a config file over the current values, unknown keys are rejected and so are
+// the UserOnlyKeys of a project file
+func (c *Config) merge(path string, required, project bool) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+
+	if project {
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return fmt.Errorf("invalid config %s: %w", path, err)
+		}
+		if key := userOnlyKey(&node, ""); key != "" {
+			return fmt.Errorf("invalid config %s: %s %w", path, key, ErrUserOnlyKey)
+		}
+	}
+
+	if err := decode(data, c); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	c.Files = append(c.Files, path)
+	return nil
+}
+
+func (c *Config) applyEnv(vars map[*string]string) {
+	for field, name := range vars {
+		if value := os.Getenv(name); value != "" {
+			*field = value
+		}
+	}
+}
+
+func (c *Config) applyListEnv(vars map[*[]string]string) {
+	for field, name := range vars {
+		if value := os.Getenv(name); value != "" {
+			*field = splitList(value)
+		}
+	}
+}
+
+func decode(data []byte, out *Config) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(out)
+	if errors.Is(err, io.EOF) {
+		// empty file
+		return nil
+	}
+	return err
+}
+
+// userOnlyKey - the first key of a YAML document that is one of UserOnlyKeys, whatever its value,
+// so a null or zero value cannot clear a user setting either. merge keys and aliases are followed
+func userOnlyKey(node *yaml.Node, prefix string) string {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if key := userOnlyKey(child, prefix); key != "" {
+				return key
+			}
+		}
+	case yaml.AliasNode:
+		return userOnlyKey(node.Alias, prefix)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i].Value, node.Content[i+1]
+			if name == "<<" {
+				if key := userOnlyKey(value, prefix); key != "" {
+					return key
+				}
+				continue
+			}
+			if UserOnly(prefix + name) {
+				return prefix + name
+			}
+			if key := userOnlyKey(value, prefix+name+"."); key != "" {
+				return key
+			}
+		}
+	case yaml.SequenceNode:
+		// the values of a merge key can be a list of mappings
+		for _, child := range node.Content {
+			if key := userOnlyKey(child, prefix); key != "" {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// UserOnly - whether a key is one of UserOnlyKeys or in a section of them
+func UserOnly(key string) bool {
+	for _, restricted := range UserOnlyKeys {
+		if key == restricted || strings.HasPrefix(key, restricted+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList - comma separated list, blanks dropped
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func mask(secret string) string {
+	if len(secret) <= 8 {
+		return "********"
+	}
+	return secret[:3] + "..." + secret[len(secret)-4:]
+}
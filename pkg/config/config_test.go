@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/provider"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProjectUserOnlyKeys(t *testing.T) {
+	for _, name := range []string{"OPENAI_BASE_URL", provider.BaseURLEnv, ExtensionsEnv, workspace.RootEnv} {
+		t.Setenv(name, "")
+	}
+
+	tests := []struct {
+		name    string
+		project string
+		wantErr bool
+	}{
+		{"plain settings", "create:\n  extensions: [.ts]\nrun:\n  timeout: 5s\n", false},
+		{"model base url", "model:\n  base_url: https://example.com/v1\n", true},
+		{"model api key", "model:\n  api_key: sk-test\n", true},
+		{"run allow", "run:\n  allow: [\"*\"]\n", true},
+		{"empty run deny", "run:\n  deny: []\n", true},
+		{"open editors", "open:\n  editors: [sh]\n", true},
+		{"null run deny", "run:\n  deny: ~\n", true},
+		{"null model section", "model: ~\n", true},
+		{"zero model timeout", "model:\n  timeout: 0s\n", true},
+		{"empty model name", "model:\n  name: \"\"\n", true},
+		{"run deny through a merge key", "run:\n  <<: {deny: ~}\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			userFile := filepath.Join(dir, "config.yaml")
+			if err := os.WriteFile(userFile, []byte("model:\n  base_url: http://localhost:11434/v1\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte(tt.project), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(Options{File: userFile, Root: dir})
+			if tt.wantErr {
+				if !errors.Is(err, ErrUserOnlyKey) {
+					t.Fatalf("Load() error = %v, want %v", err, ErrUserOnlyKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Model.BaseURL != "http://localhost:11434/v1" {
+				t.Errorf("Model.BaseURL = %q, want the user config value", cfg.Model.BaseURL)
+			}
+			if len(cfg.Create.Extensions) != 1 || cfg.Create.Extensions[0] != ".ts" {
+				t.Errorf("Create.Extensions = %v, want the project value", cfg.Create.Extensions)
+			}
+		})
+	}
+}
+
+func TestUserOnly(t *testing.T) {
+	for key, want := range map[string]bool{
+		"model.base_url":    true,
+		"model.name":        true,
+		"run.allow":         true,
+		"run.deny":          true,
+		"run.timeout":       false,
+		"open.editors":      true,
+		"create.extensions": false,
+		"modelx":            false,
+	} {
+		if got := UserOnly(key); got != want {
+			t.Errorf("UserOnly(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrUnknownKey - no setting has that name
+var ErrUnknownKey = errors.New("unknown config key")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Keys - dotted names of every setting, e.g. model.name
+func Keys() []string {
+	var keys []string
+	walkKeys(reflect.TypeOf(Config{}), "", &keys)
+	return keys
+}
+
+// Get - value of a setting by its dotted name
+func (c *Config) Get(key string) (any, error) {
+	field, err := lookup(c, key)
+	if err != nil {
+		return nil, err
+	}
+	return field.Interface(), nil
+}
+
+// Set - parse a value into a setting by its dotted name, lists are comma separated
+func (c *Config) Set(key, value string) error {
+	field, err := lookup(c, key)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration for %s: %w", key, err)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		field.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("%s cannot be set from the command line", key)
+	}
+
+	return nil
+}
+
+// FormatValue - value as printed by `config get`, lists one item per line
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, "\n")
+	case time.Duration:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// SetFile - set a key in a config file, creating the file when needed.
+// the file is edited as a YAML tree so comments and unrelated keys are kept
+func SetFile(path, key, value string) error {
+	// validate the key and value before touching the file
+	probe := &Config{}
+	if err := probe.Set(key, value); err != nil {
+		return err
+	}
+	parsed, err := probe.Get(key)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		node, err = mappingChild(node, part)
+		if err != nil {
+			return fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	}
+
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(yamlValue(parsed)); err != nil {
+		return err
+	}
+	setMappingValue(node, parts[len(parts)-1], valueNode)
+
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	// the edited file must still load
+	if err := decode([]byte(out.String()), Default()); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return writeFile(path, []byte(out.String()))
+}
+
+func walkKeys(t reflect.Type, prefix string, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" {
+			continue
+		}
+
+		if t.Field(i).Type.Kind() == reflect.Struct {
+			walkKeys(t.Field(i).Type, prefix+name+".", keys)
+			continue
+		}
+		*keys = append(*keys, prefix+name)
+	}
+}
+
+func lookup(c *Config, key string) (reflect.Value, error) {
+	v := reflect.ValueOf(c).Elem()
+
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+		}
+
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if yamlName(v.Type().Field(i)) == part {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+		}
+	}
+
+	if v.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%w: %s is a section, use one of its keys", ErrUnknownKey, key)
+	}
+
+	return v, nil
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// yamlValue - durations are written in their readable form
+func yamlValue(value any) any {
+	if d, ok := value.(time.Duration); ok {
+		return d.String()
+	}
+	return value
+}
+
+func mappingChild(node *yaml.Node, key string) (*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping above %q", key)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			child := node.Content[i+1]
+			if child.Kind == yaml.ScalarNode && child.Tag == "!!null" {
+				*child = yaml.Node{Kind: yaml.MappingNode}
+			}
+			return child, nil
+		}
+	}
+
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	return child, nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			// keep comments attached to the old value
+			value.HeadComment = node.Content[i+1].HeadComment
+			value.LineComment = node.Content[i+1].LineComment
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+// writeFile - write then rename so a crash never leaves a truncated config
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,321 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext - unchanged lines shown around every change
+const DefaultContext = 3
+
+// noNewline - marker following a line that has no newline at the end of the file
+const noNewline = `\ No newline at end of file`
+
+// Line - one line of a hunk, Kind is ' ' for context, '-' when removed and '+' when added.
+// Text keeps its newline, only the last line of a file can be without one
+type Line struct {
+	Kind byte
+	Text string
+}
+
+// Hunk - a change and the context around it, starts are 1 based as in the @@ header
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// Header - the @@ -1,3 +1,4 @@ line
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", span(h.OldStart, h.OldLines), span(h.NewStart, h.NewLines))
+}
+
+// Stats - lines added and removed
+func (h Hunk) Stats() (added, removed int) {
+	for _, line := range h.Lines {
+		switch line.Kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// Reverse - the hunk undoing h
+func (h Hunk) Reverse() Hunk {
+	reversed := Hunk{OldStart: h.NewStart, OldLines: h.NewLines, NewStart: h.OldStart, NewLines: h.OldLines}
+	for _, line := range h.Lines {
+		switch line.Kind {
+		case '+':
+			line.Kind = '-'
+		case '-':
+			line.Kind = '+'
+		}
+		reversed.Lines = append(reversed.Lines, line)
+	}
+	return reversed
+}
+
+// old and new - the lines of the hunk before and after the change
+func (h Hunk) old() []string {
+	return h.side('+')
+}
+
+func (h Hunk) new() []string {
+	return h.side('-')
+}
+
+func (h Hunk) side(skip byte) []string {
+	lines := make([]string, 0, len(h.Lines))
+	for _, line := range h.Lines {
+		if line.Kind != skip {
+			lines = append(lines, line.Text)
+		}
+	}
+	return lines
+}
+
+// String - the hunk in unified format, header included
+func (h Hunk) String() string {
+	var s strings.Builder
+	s.WriteString(h.Header() + "\n")
+	for _, line := range h.Lines {
+		s.WriteByte(line.Kind)
+		s.WriteString(line.Text)
+		if !strings.HasSuffix(line.Text, "\n") {
+			s.WriteString("\n" + noNewline + "\n")
+		}
+	}
+	return s.String()
+}
+
+func span(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// SplitLines - text as lines that keep their newline
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Compute - the hunks turning a into b with context unchanged lines around each change
+func Compute(a, b []string, context int) []Hunk {
+	ops := edits(a, b)
+
+	var hunks []Hunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// extend over changes separated by less than two contexts worth of equal lines
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+				continue
+			}
+			if j-end > 2*context {
+				break
+			}
+		}
+
+		start := max(i-context, 0)
+		stop := min(end+context+1, len(ops))
+		hunks = append(hunks, newHunk(ops[start:stop]))
+		i = stop
+	}
+
+	return hunks
+}
+
+// Unified - the hunks as a unified diff of one file
+func Unified(oldName, newName string, hunks []Hunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString("--- " + oldName + "\n")
+	s.WriteString("+++ " + newName + "\n")
+	for _, hunk := range hunks {
+		s.WriteString(hunk.String())
+	}
+	return s.String()
+}
+
+// Select - a with only the accepted hunks applied, the hunks must come from Compute on a
+func Select(a []string, hunks []Hunk, accept []bool) []string {
+	var out []string
+	pos := 0
+	for i, hunk := range hunks {
+		start := hunk.OldStart - 1
+		if hunk.OldLines == 0 {
+			start = hunk.OldStart
+		}
+
+		out = append(out, a[pos:start]...)
+		if i < len(accept) && accept[i] {
+			out = append(out, hunk.new()...)
+		} else {
+			out = append(out, hunk.old()...)
+		}
+		pos = start + hunk.OldLines
+	}
+	return append(out, a[pos:]...)
+}
+
+// op - one step of the edit script, i and j are the positions in a and b before it
+type op struct {
+	kind byte
+	i, j int
+	text string
+}
+
+func newHunk(ops []op) Hunk {
+	first := ops[0]
+	hunk := Hunk{OldStart: first.i, NewStart: first.j}
+	for _, o := range ops {
+		hunk.Lines = append(hunk.Lines, Line{Kind: o.kind, Text: o.text})
+		switch o.kind {
+		case ' ':
+			hunk.OldLines++
+			hunk.NewLines++
+		case '-':
+			hunk.OldLines++
+		case '+':
+			hunk.NewLines++
+		}
+	}
+
+	// an empty side starts at the line before it, as diff and git write it
+	if hunk.OldLines > 0 {
+		hunk.OldStart++
+	}
+	if hunk.NewLines > 0 {
+		hunk.NewStart++
+	}
+	return hunk
+}
+
+// edits - shortest edit script from a to b (Myers), common prefix and suffix are skipped first
+func edits(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for k := 0; k < prefix; k++ {
+		ops = append(ops, op{kind: ' ', i: k, j: k})
+	}
+	for _, o := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		ops = append(ops, op{kind: o.kind, i: o.i + prefix, j: o.j + prefix})
+	}
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, op{kind: ' ', i: len(a) - suffix + k, j: len(b) - suffix + k})
+	}
+
+	for k := range ops {
+		if ops[k].kind == '+' {
+			ops[k].text = b[ops[k].j]
+		} else {
+			ops[k].text = a[ops[k].i]
+		}
+	}
+	return ops
+}
+
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	limit := n + m
+	v := make([]int, 2*limit+3)
+	offset := limit + 1
+
+	// trace[d] - the furthest x of diagonals -d-1..d+1 before round d
+	var trace [][]int
+	var d int
+search:
+	for d = 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// walk back from the end, collecting the script in reverse
+	var ops []op
+	x, y := n, m
+	for ; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{kind: ' ', i: x, j: y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{kind: '+', i: x, j: y})
+		} else {
+			x--
+			ops = append(ops, op{kind: '-', i: x, j: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{kind: ' ', i: x, j: y})
+	}
+
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops
+}
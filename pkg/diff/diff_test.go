@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a\n"}},
+		{"a\nb", []string{"a\n", "b"}},
+		{"a\r\n\nb\n", []string{"a\r\n", "\n", "b\n"}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	twenty := numbered(20)
+	changed := func(a []string, at ...int) []string {
+		out := append([]string(nil), a...)
+		for _, i := range at {
+			out[i-1] = "changed\n"
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		a, b    []string
+		context int
+		headers []string
+	}{
+		{"identical", twenty, twenty, DefaultContext, nil},
+		{"one change", twenty, changed(twenty, 10), DefaultContext, []string{"@@ -7,7 +7,7 @@"}},
+		{"no context", twenty, changed(twenty, 10), 0, []string{"@@ -10 +10 @@"}},
+		{"change at the start", twenty, changed(twenty, 1), DefaultContext, []string{"@@ -1,4 +1,4 @@"}},
+		{"close changes share a hunk", twenty, changed(twenty, 5, 11), DefaultContext, []string{"@@ -2,13 +2,13 @@"}},
+		{"distant changes split", twenty, changed(twenty, 2, 18), DefaultContext, []string{"@@ -1,5 +1,5 @@", "@@ -15,6 +15,6 @@"}},
+		{"insertion", numbered(3), []string{"1\n", "2\n", "new\n", "3\n"}, 0, []string{"@@ -2,0 +3 @@"}},
+		{"deletion", numbered(3), []string{"1\n", "3\n"}, 1, []string{"@@ -1,3 +1,2 @@"}},
+		{"created", nil, []string{"a\n", "b\n"}, DefaultContext, []string{"@@ -0,0 +1,2 @@"}},
+		{"emptied", []string{"a\n"}, nil, DefaultContext, []string{"@@ -1 +0,0 @@"}},
+		{"newline added at the end", []string{"a\n", "b"}, []string{"a\n", "b\n"}, DefaultContext, []string{"@@ -1,2 +1,2 @@"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks := Compute(tt.a, tt.b, tt.context)
+
+			var headers []string
+			for _, hunk := range hunks {
+				headers = append(headers, hunk.Header())
+			}
+			if !reflect.DeepEqual(headers, tt.headers) {
+				t.Errorf("Compute() hunks %q, want %q", headers, tt.headers)
+			}
+
+			// the hunks turn a into b, whether applied or selected
+			if got, _, err := Apply(tt.a, hunks, 0); err != nil || !reflect.DeepEqual(got, tt.b) {
+				t.Errorf("Apply() of the computed hunks = %q, %v, want %q", got, err, tt.b)
+			}
+			accept := make([]bool, len(hunks))
+			for i := range accept {
+				accept[i] = true
+			}
+			if got := Select(tt.a, hunks, accept); !reflect.DeepEqual(got, tt.b) && len(got)+len(tt.b) > 0 {
+				t.Errorf("Select() of every hunk = %q, want %q", got, tt.b)
+			}
+			if got := Select(tt.a, hunks, nil); !reflect.DeepEqual(got, tt.a) && len(got)+len(tt.a) > 0 {
+				t.Errorf("Select() of no hunk = %q, want %q", got, tt.a)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	a := numbered(20)
+	b := append([]string(nil), a...)
+	b[1], b[17] = "two\n", "eighteen\n"
+
+	hunks := Compute(a, b, DefaultContext)
+	if len(hunks) != 2 {
+		t.Fatalf("Compute() returned %d hunks, want 2", len(hunks))
+	}
+
+	got := Select(a, hunks, []bool{false, true})
+	want := append([]string(nil), a...)
+	want[17] = "eighteen\n"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Select() of the second hunk = %q, want %q", got, want)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	hunks := Compute([]string{"a\n", "b"}, []string{"a\n", "c"}, DefaultContext)
+
+	want := strings.Join([]string{
+		"--- a/f",
+		"+++ b/f",
+		"@@ -1,2 +1,2 @@",
+		" a",
+		"-b",
+		noNewline,
+		"+c",
+		noNewline,
+		"",
+	}, "\n")
+	got := Unified("a/f", "b/f", hunks)
+	if got != want {
+		t.Errorf("Unified() = %q, want %q", got, want)
+	}
+
+	// what Unified writes Parse reads back
+	files, err := Parse(got)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(files[0].Hunks, hunks) {
+		t.Errorf("Parse() hunks = %+v, want %+v", files[0].Hunks, hunks)
+	}
+
+	if got := Unified("a/f", "b/f", nil); got != "" {
+		t.Errorf("Unified() without hunks = %q, want nothing", got)
+	}
+}
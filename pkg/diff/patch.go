@@ -0,0 +1,382 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch - the patch text is not a unified diff
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrHunkFailed - the lines a hunk changes are not in the file
+	ErrHunkFailed = errors.New("hunk does not apply")
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// DevNull - name of the missing side of a created or deleted file
+const DevNull = "/dev/null"
+
+// DefaultFuzz - context lines at either end of a hunk that may be left out when it does not apply as is
+const DefaultFuzz = 2
+
+// File - the hunks of one file in a patch, names as written after --- and +++ or in git's headers.
+// Renamed is set by git's rename headers, the names of other diffs may differ for the same file
+// (diff -u foo.txt.orig foo.txt). the modes are git's octal file modes, empty when not given
+type File struct {
+	OldName string
+	NewName string
+	Renamed bool
+	OldMode string
+	NewMode string
+	Hunks   []Hunk
+}
+
+// Created - the patch makes the file
+func (f *File) Created() bool {
+	return f.OldName == DevNull
+}
+
+// Deleted - the patch removes the file
+func (f *File) Deleted() bool {
+	return f.NewName == DevNull
+}
+
+// Paths - old and new name without their first strip directories as patch -p does, empty for
+// /dev/null. strip below 0 drops the a/ and b/ prefixes git writes when both names have them
+func (f *File) Paths(strip int) (string, string) {
+	if strip < 0 {
+		strip = 0
+		if gitPrefixed(f.OldName) && gitPrefixed(f.NewName) {
+			strip = 1
+		}
+	}
+	return stripName(f.OldName, strip), stripName(f.NewName, strip)
+}
+
+// gitPrefixed - the name starts with a/ or b/, which are swapped in a reversed patch
+func gitPrefixed(name string) bool {
+	return name == DevNull || strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/")
+}
+
+func stripName(name string, strip int) string {
+	if name == DevNull {
+		return ""
+	}
+	for ; strip > 0; strip-- {
+		_, rest, ok := strings.Cut(name, "/")
+		if !ok {
+			break
+		}
+		name = rest
+	}
+	return name
+}
+
+// Reverse - the patch undoing f
+func (f *File) Reverse() *File {
+	reversed := &File{OldName: f.NewName, NewName: f.OldName, Renamed: f.Renamed, OldMode: f.NewMode, NewMode: f.OldMode}
+	for _, hunk := range f.Hunks {
+		reversed.Hunks = append(reversed.Hunks, hunk.Reverse())
+	}
+	return reversed
+}
+
+// Parse - the files of a unified diff, text before the first --- or @@ line is ignored.
+// git's diff --git, new file, deleted file, mode and rename headers are read, a file without hunks
+// is an empty file created or deleted or a pure rename. hunks without --- and +++ headers
+// belong to one unnamed file
+func Parse(patch string) ([]*File, error) {
+	var files []*File
+	var file *File
+	header := false // in the git header of file, before its first hunk
+
+	lines := SplitLines(patch)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			oldName, newName := gitNames(line[len("diff --git "):])
+			file = &File{OldName: oldName, NewName: newName}
+			files = append(files, file)
+			header = true
+
+		case header && strings.HasPrefix(line, "new file mode "):
+			file.OldName, file.NewMode = DevNull, line[len("new file mode "):]
+		case header && strings.HasPrefix(line, "deleted file mode "):
+			file.NewName, file.OldMode = DevNull, line[len("deleted file mode "):]
+		case header && strings.HasPrefix(line, "old mode "):
+			file.OldMode = line[len("old mode "):]
+		case header && strings.HasPrefix(line, "new mode "):
+			file.NewMode = line[len("new mode "):]
+		case header && strings.HasPrefix(line, "rename from "):
+			file.OldName, file.Renamed = "a/"+unquote(line[len("rename from "):]), true
+		case header && strings.HasPrefix(line, "rename to "):
+			file.NewName, file.Renamed = "b/"+unquote(line[len("rename to "):]), true
+		case header && (strings.HasPrefix(line, "copy from ") || strings.HasPrefix(line, "copy to ")):
+			return nil, fmt.Errorf("%w: copies are not supported, %s", ErrInvalidPatch, line)
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			return nil, fmt.Errorf("%w: binary patches are not supported", ErrInvalidPatch)
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			oldName, newName := fileName(line[4:]), fileName(strings.TrimRight(lines[i+1][4:], "\r\n"))
+			if !header {
+				file = &File{}
+				files = append(files, file)
+			}
+			file.OldName, file.NewName = oldName, newName
+			header = false
+			i++
+
+		case strings.HasPrefix(line, "@@"):
+			if file == nil {
+				file = &File{}
+				files = append(files, file)
+			}
+			header = false
+
+			hunk, n, err := parseHunk(lines[i:])
+			if err != nil {
+				return nil, err
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			i += n - 1
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no hunks found", ErrInvalidPatch)
+	}
+	return files, nil
+}
+
+// gitNames - the a/ and b/ names of a diff --git line, the same path twice unless the file is renamed
+func gitNames(names string) (string, string) {
+	if strings.HasPrefix(names, `"`) {
+		if end := closingQuote(names); end > 0 {
+			return unquote(names[:end+1]), unquote(strings.TrimSpace(names[end+1:]))
+		}
+	}
+
+	// a/x b/x, which may have spaces in it
+	if half := len(names) / 2; len(names)%2 == 1 && names[half] == ' ' && names[2:half] == names[half+3:] {
+		return names[:half], names[half+1:]
+	}
+	if oldName, newName, ok := strings.Cut(names, " b/"); ok {
+		return oldName, "b/" + newName
+	}
+	oldName, newName, _ := strings.Cut(names, " ")
+	return oldName, unquote(newName)
+}
+
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote - a name git quoted for its special characters
+func unquote(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			return unquoted
+		}
+	}
+	return name
+}
+
+// parseHunk - the hunk at the start of lines and the number of lines it took, header included
+func parseHunk(lines []string) (Hunk, int, error) {
+	header := strings.TrimRight(lines[0], "\r\n")
+	match := hunkHeader.FindStringSubmatch(header)
+	if match == nil {
+		return Hunk{}, 0, fmt.Errorf("%w: bad hunk header %q", ErrInvalidPatch, header)
+	}
+
+	hunk := Hunk{
+		OldStart: atoi(match[1], 0),
+		OldLines: atoi(match[2], 1),
+		NewStart: atoi(match[3], 0),
+		NewLines: atoi(match[4], 1),
+	}
+
+	oldLeft, newLeft := hunk.OldLines, hunk.NewLines
+	n := 1
+	for ; n < len(lines) && (oldLeft > 0 || newLeft > 0); n++ {
+		text := lines[n]
+
+		// editors strip the trailing space of empty context lines
+		if text == "\n" || text == "\r\n" {
+			text = " " + text
+		}
+
+		kind, body := text[0], text[1:]
+		switch kind {
+		case ' ':
+			oldLeft--
+			newLeft--
+		case '-':
+			oldLeft--
+		case '+':
+			newLeft--
+		case '\\':
+			hunk.trimNewline()
+			continue
+		default:
+			return Hunk{}, 0, fmt.Errorf("%w: unexpected line %q in hunk %s", ErrInvalidPatch, strings.TrimRight(text, "\r\n"), header)
+		}
+		if !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: body})
+	}
+
+	if oldLeft != 0 || newLeft != 0 {
+		return Hunk{}, 0, fmt.Errorf("%w: hunk %s is cut short", ErrInvalidPatch, header)
+	}
+
+	// a no newline marker after the last line
+	if n < len(lines) && strings.HasPrefix(lines[n], `\`) {
+		hunk.trimNewline()
+		n++
+	}
+
+	return hunk, n, nil
+}
+
+// trimNewline - the last line has no newline at the end of the file
+func (h *Hunk) trimNewline() {
+	if len(h.Lines) > 0 {
+		last := &h.Lines[len(h.Lines)-1]
+		last.Text = strings.TrimSuffix(last.Text, "\n")
+	}
+}
+
+// fileName - path of a --- or +++ line without the timestamp diff appends after a tab
+func fileName(name string) string {
+	name, _, _ = strings.Cut(name, "\t")
+	return unquote(strings.TrimSpace(name))
+}
+
+func atoi(s string, fallback int) int {
+	if s == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// Placement - where a hunk was applied, Offset lines away from the line its header names
+// and with Fuzz context lines at either end left out
+type Placement struct {
+	Offset int
+	Fuzz   int
+}
+
+// Apply - a with the hunks applied in order. a hunk whose lines moved is searched for
+// above and below the line its header names, its placement reports how far it moved.
+// a hunk found nowhere is tried again without up to fuzz context lines at either end
+func Apply(a []string, hunks []Hunk, fuzz int) ([]string, []Placement, error) {
+	var out []string
+	placements := make([]Placement, 0, len(hunks))
+	pos, shift := 0, 0
+
+	for i, hunk := range hunks {
+		at, applied, placement, ok := -1, hunk, Placement{}, false
+		for f := 0; f <= fuzz && !ok; f++ {
+			applied, ok = hunk.trimContext(f)
+			if !ok {
+				break
+			}
+
+			// the line the header names, and where earlier hunks that moved suggest it is now
+			named := applied.OldStart - 1
+			if applied.OldLines == 0 {
+				named = applied.OldStart
+			}
+			expected := min(max(named+shift, pos), len(a))
+
+			at, ok = find(a, applied.old(), expected, pos)
+			placement = Placement{Offset: at - named, Fuzz: f}
+		}
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: hunk %d (%s)", ErrHunkFailed, i+1, hunk.Header())
+		}
+
+		out = append(out, a[pos:at]...)
+		out = append(out, applied.new()...)
+		pos = at + applied.OldLines
+
+		placements = append(placements, placement)
+		shift = placement.Offset
+	}
+
+	return append(out, a[pos:]...), placements, nil
+}
+
+// trimContext - the hunk without up to n context lines at either end, false once
+// nothing is left to match where the hunk had lines to match
+func (h Hunk) trimContext(n int) (Hunk, bool) {
+	lead := 0
+	for lead < n && lead < len(h.Lines) && h.Lines[lead].Kind == ' ' {
+		lead++
+	}
+	trail := 0
+	for trail < n && trail < len(h.Lines)-lead && h.Lines[len(h.Lines)-1-trail].Kind == ' ' {
+		trail++
+	}
+	if n > 0 && lead+trail == 0 {
+		return h, false
+	}
+	if lead+trail > 0 && lead+trail == h.OldLines {
+		return h, false
+	}
+
+	trimmed := Hunk{
+		OldStart: h.OldStart + lead,
+		OldLines: h.OldLines - lead - trail,
+		NewStart: h.NewStart + lead,
+		NewLines: h.NewLines - lead - trail,
+		Lines:    h.Lines[lead : len(h.Lines)-trail],
+	}
+	return trimmed, true
+}
+
+// find - the position of lines in a nearest to expected, not before from
+func find(a, lines []string, expected, from int) (int, bool) {
+	for delta := 0; ; delta++ {
+		below, above := expected+delta, expected-delta
+		if below+len(lines) > len(a) && above < from {
+			return 0, false
+		}
+		if below+len(lines) <= len(a) && matchAt(a, lines, below) {
+			return below, true
+		}
+		if delta > 0 && above >= from && above+len(lines) <= len(a) && matchAt(a, lines, above) {
+			return above, true
+		}
+	}
+}
+
+func matchAt(a, lines []string, at int) bool {
+	for k, line := range lines {
+		if a[at+k] != line {
+			return false
+		}
+	}
+	return true
+}
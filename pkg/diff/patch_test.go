@@ -0,0 +1,445 @@
+package diff
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// lines - hunk lines written as their kind followed by the text, " a\n" is the context line a
+func lines(specs ...string) []Line {
+	out := make([]Line, len(specs))
+	for i, spec := range specs {
+		out[i] = Line{Kind: spec[0], Text: spec[1:]}
+	}
+	return out
+}
+
+// numbered - the lines "1\n" to "n\n"
+func numbered(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = strconv.Itoa(i+1) + "\n"
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  []*File
+	}{
+		{
+			name:  "plain diff with timestamps",
+			patch: "--- old.txt\t2024-01-01 10:00:00\n+++ new.txt\t2024-01-02 10:00:00\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+			want: []*File{{OldName: "old.txt", NewName: "new.txt", Hunks: []Hunk{
+				{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: lines(" a\n", "-b\n", "+c\n")},
+			}}},
+		},
+		{
+			name:  "text before the diff and two files",
+			patch: "commit message\n\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-x\n+X\n--- a/y\n+++ b/y\n@@ -3 +3 @@\n-y\n+Y\n",
+			want: []*File{
+				{OldName: "a/x", NewName: "b/x", Hunks: []Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: lines("-x\n", "+X\n")}}},
+				{OldName: "a/y", NewName: "b/y", Hunks: []Hunk{{OldStart: 3, OldLines: 1, NewStart: 3, NewLines: 1, Lines: lines("-y\n", "+Y\n")}}},
+			},
+		},
+		{
+			name:  "hunks without file headers",
+			patch: "@@ -1 +1 @@\n-a\n+b\n",
+			want:  []*File{{Hunks: []Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: lines("-a\n", "+b\n")}}}},
+		},
+		{
+			name:  "empty context line with its space stripped",
+			patch: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n",
+			want: []*File{{OldName: "a", NewName: "b", Hunks: []Hunk{
+				{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3, Lines: lines(" a\n", " \n", "-b\n", "+c\n")},
+			}}},
+		},
+		{
+			name:  "no newline at end of file",
+			patch: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n keep\n-old\n\\ No newline at end of file\n+new\n\\ No newline at end of file\n",
+			want: []*File{{OldName: "a", NewName: "b", Hunks: []Hunk{
+				{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: lines(" keep\n", "-old", "+new")},
+			}}},
+		},
+		{
+			name:  "git new file",
+			patch: "diff --git a/new.txt b/new.txt\nnew file mode 100644\nindex 0000000..ce01362\n--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hello\n",
+			want: []*File{{OldName: DevNull, NewName: "b/new.txt", NewMode: "100644", Hunks: []Hunk{
+				{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1, Lines: lines("+hello\n")},
+			}}},
+		},
+		{
+			name:  "git new empty file",
+			patch: "diff --git a/empty b/empty\nnew file mode 100644\nindex 0000000..e69de29\n",
+			want:  []*File{{OldName: DevNull, NewName: "b/empty", NewMode: "100644"}},
+		},
+		{
+			name:  "git deleted file",
+			patch: "diff --git a/gone.txt b/gone.txt\ndeleted file mode 100644\nindex ce01362..0000000\n--- a/gone.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n",
+			want: []*File{{OldName: "a/gone.txt", NewName: DevNull, OldMode: "100644", Hunks: []Hunk{
+				{OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0, Lines: lines("-bye\n")},
+			}}},
+		},
+		{
+			name:  "git pure rename",
+			patch: "diff --git a/old.go b/new.go\nsimilarity index 100%\nrename from old.go\nrename to new.go\n",
+			want:  []*File{{OldName: "a/old.go", NewName: "b/new.go", Renamed: true}},
+		},
+		{
+			name:  "git rename with changes",
+			patch: "diff --git a/old.go b/pkg/new.go\nsimilarity index 90%\nrename from old.go\nrename to pkg/new.go\nindex 1..2 100644\n--- a/old.go\n+++ b/pkg/new.go\n@@ -1 +1 @@\n-package old\n+package pkg\n",
+			want: []*File{{OldName: "a/old.go", NewName: "b/pkg/new.go", Renamed: true, Hunks: []Hunk{
+				{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: lines("-package old\n", "+package pkg\n")},
+			}}},
+		},
+		{
+			name:  "git names with spaces",
+			patch: "diff --git a/my file.txt b/my file.txt\nnew file mode 100644\n",
+			want:  []*File{{OldName: DevNull, NewName: "b/my file.txt", NewMode: "100644"}},
+		},
+		{
+			name:  "git quoted names",
+			patch: "diff --git \"a/caf\\303\\251.txt\" \"b/tab\\there.txt\"\nsimilarity index 100%\nrename from \"caf\\303\\251.txt\"\nrename to \"tab\\there.txt\"\n",
+			want:  []*File{{OldName: "a/café.txt", NewName: "b/tab\there.txt", Renamed: true}},
+		},
+		{
+			name:  "git mode change",
+			patch: "diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n",
+			want:  []*File{{OldName: "a/run.sh", NewName: "b/run.sh", OldMode: "100644", NewMode: "100755"}},
+		},
+		{
+			name:  "plain diff of two names is not a rename",
+			patch: "--- foo.txt.orig\n+++ foo.txt\n@@ -1 +1 @@\n-a\n+b\n",
+			want: []*File{{OldName: "foo.txt.orig", NewName: "foo.txt", Hunks: []Hunk{
+				{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: lines("-a\n", "+b\n")},
+			}}},
+		},
+		{
+			name:  "quoted --- and +++ names",
+			patch: "--- \"a/caf\\303\\251.txt\"\n+++ \"b/caf\\303\\251.txt\"\n@@ -1 +1 @@\n-a\n+b\n",
+			want: []*File{{OldName: "a/café.txt", NewName: "b/café.txt", Hunks: []Hunk{
+				{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: lines("-a\n", "+b\n")},
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.patch)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				for _, f := range got {
+					t.Logf("got %+v", *f)
+				}
+				t.Errorf("Parse() did not return the wanted files")
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"no hunks", "just some text\n"},
+		{"empty", ""},
+		{"cut short", "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n"},
+		{"unexpected line", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n*b\n"},
+		{"bad hunk header", "--- a\n+++ b\n@@ -x +1 @@\n a\n"},
+		{"copy", "diff --git a/x b/y\nsimilarity index 100%\ncopy from x\ncopy to y\n"},
+		{"binary", "diff --git a/img.png b/img.png\nBinary files a/img.png and b/img.png differ\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if files, err := Parse(tt.patch); !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("Parse() = %v, %v, want %v", files, err, ErrInvalidPatch)
+			}
+		})
+	}
+}
+
+func TestFilePaths(t *testing.T) {
+	tests := []struct {
+		name             string
+		file             File
+		strip            int
+		wantOld, wantNew string
+	}{
+		{"git prefixes dropped", File{OldName: "a/cmd/x.go", NewName: "b/cmd/x.go"}, -1, "cmd/x.go", "cmd/x.go"},
+		{"git created file", File{OldName: DevNull, NewName: "b/x.go"}, -1, "", "x.go"},
+		{"reversed git patch", File{OldName: "b/x.go", NewName: "a/x.go"}, -1, "x.go", "x.go"},
+		{"plain names kept", File{OldName: "x.go", NewName: "cmd/x.go"}, -1, "x.go", "cmd/x.go"},
+		{"strip 0", File{OldName: "a/x.go", NewName: "b/x.go"}, 0, "a/x.go", "b/x.go"},
+		{"strip 2", File{OldName: "src/pkg/x.go", NewName: "dst/pkg/x.go"}, 2, "x.go", "x.go"},
+		{"strip past the name", File{OldName: "a/x.go", NewName: "b/x.go"}, 5, "x.go", "x.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotOld, gotNew := tt.file.Paths(tt.strip); gotOld != tt.wantOld || gotNew != tt.wantNew {
+				t.Errorf("Paths(%d) = %q, %q, want %q, %q", tt.strip, gotOld, gotNew, tt.wantOld, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	// changes 5 to five with a line of context either side
+	changeFive := Hunk{OldStart: 4, OldLines: 3, NewStart: 4, NewLines: 3, Lines: lines(" 4\n", "-5\n", "+five\n", " 6\n")}
+
+	withFive := func(a []string) []string {
+		out := append([]string(nil), a...)
+		for i, line := range out {
+			if line == "5\n" {
+				out[i] = "five\n"
+			}
+		}
+		return out
+	}
+
+	shifted := append([]string{"x\n", "y\n"}, numbered(10)...)
+	changedContext := numbered(10)
+	changedContext[6] = "seven\n"
+	noNewline := numbered(3)
+	noNewline[2] = "3"
+
+	tests := []struct {
+		name       string
+		a          []string
+		hunks      []Hunk
+		fuzz       int
+		want       []string
+		placements []Placement
+		wantErr    bool
+	}{
+		{
+			name:       "in place",
+			a:          numbered(10),
+			hunks:      []Hunk{changeFive},
+			want:       withFive(numbered(10)),
+			placements: []Placement{{}},
+		},
+		{
+			name:       "moved down",
+			a:          shifted,
+			hunks:      []Hunk{changeFive},
+			want:       withFive(shifted),
+			placements: []Placement{{Offset: 2}},
+		},
+		{
+			name:       "moved up",
+			a:          numbered(10)[2:],
+			hunks:      []Hunk{changeFive},
+			want:       withFive(numbered(10)[2:]),
+			placements: []Placement{{Offset: -2}},
+		},
+		{
+			name: "later hunk follows the offset of an earlier one",
+			a:    shifted,
+			hunks: []Hunk{
+				changeFive,
+				{OldStart: 8, OldLines: 2, NewStart: 8, NewLines: 1, Lines: lines(" 8\n", "-9\n")},
+			},
+			want:       []string{"x\n", "y\n", "1\n", "2\n", "3\n", "4\n", "five\n", "6\n", "7\n", "8\n", "10\n"},
+			placements: []Placement{{Offset: 2}, {Offset: 2}},
+		},
+		{
+			name:       "changed context with fuzz",
+			a:          changedContext,
+			hunks:      []Hunk{{OldStart: 4, OldLines: 4, NewStart: 4, NewLines: 4, Lines: lines(" 4\n", "-5\n", "+five\n", " 6\n", " 7\n")}},
+			fuzz:       1,
+			want:       withFive(changedContext),
+			placements: []Placement{{Fuzz: 1}},
+		},
+		{
+			name:    "changed context without fuzz",
+			a:       changedContext,
+			hunks:   []Hunk{{OldStart: 4, OldLines: 4, NewStart: 4, NewLines: 4, Lines: lines(" 4\n", "-5\n", "+five\n", " 6\n", " 7\n")}},
+			wantErr: true,
+		},
+		{
+			name:    "changed line never fuzzed away",
+			a:       numbered(10),
+			hunks:   []Hunk{{OldStart: 5, OldLines: 1, NewStart: 5, NewLines: 1, Lines: lines("-fifty\n", "+five\n")}},
+			fuzz:    DefaultFuzz,
+			wantErr: true,
+		},
+		{
+			name:       "addition at the top",
+			a:          numbered(2),
+			hunks:      []Hunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1, Lines: lines("+0\n")}},
+			want:       []string{"0\n", "1\n", "2\n"},
+			placements: []Placement{{}},
+		},
+		{
+			name:       "new file",
+			hunks:      []Hunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 2, Lines: lines("+a\n", "+b\n")}},
+			want:       []string{"a\n", "b\n"},
+			placements: []Placement{{}},
+		},
+		{
+			name:       "last line without a newline",
+			a:          noNewline,
+			hunks:      []Hunk{{OldStart: 2, OldLines: 2, NewStart: 2, NewLines: 2, Lines: lines(" 2\n", "-3", "+three\n")}},
+			want:       []string{"1\n", "2\n", "three\n"},
+			placements: []Placement{{}},
+		},
+		{
+			name:    "newline differs",
+			a:       noNewline,
+			hunks:   []Hunk{{OldStart: 3, OldLines: 1, NewStart: 3, NewLines: 1, Lines: lines("-3\n", "+three\n")}},
+			wantErr: true,
+		},
+		{
+			name: "hunks out of order",
+			a:    numbered(10),
+			hunks: []Hunk{
+				{OldStart: 8, OldLines: 1, NewStart: 8, NewLines: 1, Lines: lines("-8\n", "+eight\n")},
+				{OldStart: 2, OldLines: 1, NewStart: 2, NewLines: 1, Lines: lines("-2\n", "+two\n")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, placements, err := Apply(tt.a, tt.hunks, tt.fuzz)
+			if tt.wantErr {
+				if !errors.Is(err, ErrHunkFailed) {
+					t.Fatalf("Apply() error = %v, want %v", err, ErrHunkFailed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(placements, tt.placements) {
+				t.Errorf("Apply() placements = %+v, want %+v", placements, tt.placements)
+			}
+		})
+	}
+}
+
+func TestApplyReverse(t *testing.T) {
+	patch := "diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n-3\n+three\n@@ -8,3 +9,3 @@\n 8\n-9\n+nine\n 10\n"
+	files, err := Parse(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := numbered(10)
+	b, _, err := Apply(a, files[0].Hunks, 0)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	reversed := files[0].Reverse()
+	if reversed.OldName != "b/f" || reversed.NewName != "a/f" {
+		t.Errorf("Reverse() names = %q, %q, want them swapped", reversed.OldName, reversed.NewName)
+	}
+	back, _, err := Apply(b, reversed.Hunks, 0)
+	if err != nil {
+		t.Fatalf("Apply() of the reversed patch error = %v", err)
+	}
+	if !reflect.DeepEqual(back, a) {
+		t.Errorf("reversed patch gave %q, want %q", back, a)
+	}
+
+	// the reversed patch does not apply twice
+	if _, _, err := Apply(back, reversed.Hunks, DefaultFuzz); !errors.Is(err, ErrHunkFailed) {
+		t.Errorf("Apply() of the reversed patch to the original error = %v, want %v", err, ErrHunkFailed)
+	}
+}
+
+func TestTrimContext(t *testing.T) {
+	hunk := Hunk{OldStart: 10, OldLines: 5, NewStart: 10, NewLines: 5, Lines: lines(" a\n", " b\n", "-c\n", "+C\n", " d\n", " e\n")}
+
+	tests := []struct {
+		name   string
+		hunk   Hunk
+		n      int
+		want   Hunk
+		wantOK bool
+	}{
+		{"no fuzz", hunk, 0, hunk, true},
+		{
+			name:   "one line either end",
+			hunk:   hunk,
+			n:      1,
+			want:   Hunk{OldStart: 11, OldLines: 3, NewStart: 11, NewLines: 3, Lines: lines(" b\n", "-c\n", "+C\n", " d\n")},
+			wantOK: true,
+		},
+		{
+			name:   "stops at the change",
+			hunk:   hunk,
+			n:      3,
+			want:   Hunk{OldStart: 12, OldLines: 1, NewStart: 12, NewLines: 1, Lines: lines("-c\n", "+C\n")},
+			wantOK: true,
+		},
+		{
+			name:   "uneven context",
+			hunk:   Hunk{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3, Lines: lines("-a\n", "+A\n", " b\n", " c\n")},
+			n:      2,
+			want:   Hunk{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: lines("-a\n", "+A\n")},
+			wantOK: true,
+		},
+		{"no context to trim", Hunk{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: lines("-a\n", "+A\n")}, 1, Hunk{}, false},
+		{"addition left with nothing to match", Hunk{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 3, Lines: lines(" a\n", "+b\n", " c\n")}, 1, Hunk{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.hunk.trimContext(tt.n)
+			if ok != tt.wantOK {
+				t.Fatalf("trimContext(%d) ok = %v, want %v", tt.n, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trimContext(%d) = %+v, want %+v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	a := []string{"x\n", "y\n", "x\n", "y\n", "z\n"}
+	xy := []string{"x\n", "y\n"}
+
+	tests := []struct {
+		name     string
+		lines    []string
+		expected int
+		from     int
+		want     int
+		wantOK   bool
+	}{
+		{"where expected", xy, 2, 0, 2, true},
+		{"below wins a tie", xy, 1, 0, 2, true},
+		{"above", xy, 3, 0, 2, true},
+		{"nearest of two", xy, 0, 0, 0, true},
+		{"not before from", xy, 3, 3, 0, false},
+		{"at the end", []string{"z\n"}, 0, 0, 4, true},
+		{"missing", []string{"w\n"}, 2, 0, 0, false},
+		{"longer than the file", append(a, "w\n"), 0, 0, 0, false},
+		{"nothing to match", nil, 3, 0, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := find(a, tt.lines, tt.expected, tt.from)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("find(%d, %d) = %d, %v, want %d, %v", tt.expected, tt.from, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
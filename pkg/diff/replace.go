@@ -0,0 +1,110 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// markers of a search/replace block
+const (
+	searchMarker  = "<<<<<<< SEARCH"
+	dividerMarker = "======="
+	replaceMarker = ">>>>>>> REPLACE"
+)
+
+var (
+	// ErrInvalidBlocks - search/replace text that does not follow the block format
+	ErrInvalidBlocks = errors.New("invalid search/replace blocks")
+
+	// ErrNoMatch - the search text of a replacement is not in the content
+	ErrNoMatch = errors.New("search text not found")
+
+	// ErrAmbiguous - the search text of a replacement is in the content more than once
+	ErrAmbiguous = errors.New("search text found more than once")
+)
+
+// Replacement - exact text to find once and what replaces it
+type Replacement struct {
+	Search  string `json:"search"`
+	Replace string `json:"replace"`
+}
+
+// HasBlocks - text holds search/replace blocks
+func HasBlocks(text string) bool {
+	for _, line := range SplitLines(text) {
+		if strings.TrimRight(line, "\r\n") == searchMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseBlocks - replacements written as
+//
+//	<<<<<<< SEARCH
+//	old lines
+//	=======
+//	new lines
+//	>>>>>>> REPLACE
+//
+// text between blocks is ignored
+func ParseBlocks(text string) ([]Replacement, error) {
+	var replacements []Replacement
+	var search, replace strings.Builder
+	state := 0 // 0 outside a block, 1 in the search part, 2 in the replace part
+
+	for n, line := range SplitLines(text) {
+		marker := strings.TrimRight(line, "\r\n")
+		switch {
+		case state == 0 && marker == searchMarker:
+			search.Reset()
+			replace.Reset()
+			state = 1
+		case state == 1 && marker == dividerMarker:
+			state = 2
+		case state == 2 && marker == replaceMarker:
+			replacements = append(replacements, Replacement{Search: search.String(), Replace: replace.String()})
+			state = 0
+		case state == 0:
+		case marker == searchMarker || marker == dividerMarker || marker == replaceMarker:
+			return nil, fmt.Errorf("%w: unexpected %q on line %d", ErrInvalidBlocks, marker, n+1)
+		case state == 1:
+			search.WriteString(line)
+		case state == 2:
+			replace.WriteString(line)
+		}
+	}
+
+	if state != 0 {
+		return nil, fmt.Errorf("%w: last block is not closed with %s", ErrInvalidBlocks, replaceMarker)
+	}
+	if len(replacements) == 0 {
+		return nil, fmt.Errorf("%w: no blocks found", ErrInvalidBlocks)
+	}
+	return replacements, nil
+}
+
+// Replace - content with every replacement made in order, each search text must be found exactly once
+func Replace(content string, replacements []Replacement) (string, error) {
+	for i, r := range replacements {
+		if r.Search == "" {
+			return "", fmt.Errorf("%w: replacement %d has an empty search text", ErrInvalidBlocks, i+1)
+		}
+
+		switch strings.Count(content, r.Search) {
+		case 0:
+			return "", fmt.Errorf("%w: replacement %d, %q", ErrNoMatch, i+1, firstLine(r.Search))
+		case 1:
+			content = strings.Replace(content, r.Search, r.Replace, 1)
+		default:
+			return "", fmt.Errorf("%w: replacement %d, %q, add surrounding lines to tell them apart", ErrAmbiguous, i+1, firstLine(r.Search))
+		}
+	}
+	return content, nil
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimLeft(text, "\n"), "\n")
+	return line
+}
@@ -0,0 +1,137 @@
+package diff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []Replacement
+		wantErr bool
+	}{
+		{
+			name: "one block",
+			text: "<<<<<<< SEARCH\nold\n=======\nnew\n>>>>>>> REPLACE\n",
+			want: []Replacement{{Search: "old\n", Replace: "new\n"}},
+		},
+		{
+			name: "text around and between blocks",
+			text: "first change:\n<<<<<<< SEARCH\na\n=======\nA\n>>>>>>> REPLACE\nthen:\n<<<<<<< SEARCH\nb\nc\n=======\n>>>>>>> REPLACE\ndone\n",
+			want: []Replacement{{Search: "a\n", Replace: "A\n"}, {Search: "b\nc\n", Replace: ""}},
+		},
+		{
+			name: "windows line endings",
+			text: "<<<<<<< SEARCH\r\nold\r\n=======\r\nnew\r\n>>>>>>> REPLACE\r\n",
+			want: []Replacement{{Search: "old\r\n", Replace: "new\r\n"}},
+		},
+		{name: "no blocks", text: "just text\n", wantErr: true},
+		{name: "not closed", text: "<<<<<<< SEARCH\nold\n=======\nnew\n", wantErr: true},
+		{name: "no divider", text: "<<<<<<< SEARCH\nold\n>>>>>>> REPLACE\n", wantErr: true},
+		{name: "nested search", text: "<<<<<<< SEARCH\n<<<<<<< SEARCH\n=======\n>>>>>>> REPLACE\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBlocks(tt.text)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidBlocks) {
+					t.Errorf("ParseBlocks() = %+v, %v, want %v", got, err, ErrInvalidBlocks)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBlocks() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBlocks() = %+v, want %+v", got, tt.want)
+			}
+			if !HasBlocks(tt.text) {
+				t.Errorf("HasBlocks() = false for blocks")
+			}
+		})
+	}
+
+	if HasBlocks("@@ -1 +1 @@\n-a\n+b\n") {
+		t.Errorf("HasBlocks() = true for a diff")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	content := "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 1\n}\n"
+
+	tests := []struct {
+		name         string
+		replacements []Replacement
+		want         string
+		wantErr      error
+	}{
+		{
+			name:         "unique search",
+			replacements: []Replacement{{Search: "func a() {\n\treturn 1\n", Replace: "func a() {\n\treturn 2\n"}},
+			want:         "func a() {\n\treturn 2\n}\n\nfunc b() {\n\treturn 1\n}\n",
+		},
+		{
+			name: "in order, later searches see earlier replacements",
+			replacements: []Replacement{
+				{Search: "func a", Replace: "func c"},
+				{Search: "func c() {\n\treturn 1", Replace: "func c() {\n\treturn 3"},
+			},
+			want: "func c() {\n\treturn 3\n}\n\nfunc b() {\n\treturn 1\n}\n",
+		},
+		{
+			name:         "deletion",
+			replacements: []Replacement{{Search: "\nfunc b() {\n\treturn 1\n}\n", Replace: ""}},
+			want:         "func a() {\n\treturn 1\n}\n",
+		},
+		{
+			name:         "ambiguous",
+			replacements: []Replacement{{Search: "\treturn 1\n", Replace: "\treturn 2\n"}},
+			wantErr:      ErrAmbiguous,
+		},
+		{
+			name:         "missing",
+			replacements: []Replacement{{Search: "func d()", Replace: "func e()"}},
+			wantErr:      ErrNoMatch,
+		},
+		{
+			name:         "whitespace must match exactly",
+			replacements: []Replacement{{Search: "    return 1\n", Replace: "    return 2\n"}},
+			wantErr:      ErrNoMatch,
+		},
+		{
+			name: "second replacement missing",
+			replacements: []Replacement{
+				{Search: "func a", Replace: "func c"},
+				{Search: "func a", Replace: "func d"},
+			},
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:         "empty search",
+			replacements: []Replacement{{Search: "", Replace: "x"}},
+			wantErr:      ErrInvalidBlocks,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Replace(content, tt.replacements)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Replace() = %q, %v, want %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Replace() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Replace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
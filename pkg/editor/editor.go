@@ -0,0 +1,243 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ViewerName - the built in viewer, listed like any other editor
+const ViewerName = "default"
+
+// sources an editor can come from
+const (
+	SourceConfig = "config"
+	SourceVisual = "$VISUAL"
+	SourceEditor = "$EDITOR"
+)
+
+var (
+	// ErrNotFound - no editor with that name
+	ErrNotFound = errors.New("editor not found")
+
+	// ErrNotInstalled - the editor program is not on PATH
+	ErrNotInstalled = errors.New("editor not installed")
+)
+
+// Editor - a program files are opened with
+type Editor struct {
+	Name    string   // as listed, e.g. Code or nvim --clean
+	Program string   // looked up on PATH
+	Args    []string // passed before the file, may hold {file}, {line} and {col} placeholders
+	Source  string
+
+	// Terminal - runs in the terminal, any TUI is suspended until it exits
+	Terminal bool
+
+	// Viewer - the built in viewer, there is no program to run
+	Viewer bool
+
+	position positionFunc
+}
+
+// positionFunc - arguments opening file at line and column, both 1 based, 0 when unknown
+type positionFunc func(file string, line, col int) []string
+
+// kind - what is known about an editor program
+type kind struct {
+	terminal bool
+	position positionFunc
+}
+
+// known - editors by program name. anything else is assumed to be a terminal editor
+// opened without a position, since that is what $EDITOR usually holds
+var known = map[string]kind{
+	"vi":            {terminal: true, position: plusLine},
+	"vim":           {terminal: true, position: vimPosition},
+	"nvim":          {terminal: true, position: vimPosition},
+	"nano":          {terminal: true, position: nanoPosition},
+	"micro":         {terminal: true, position: fileLineCol},
+	"hx":            {terminal: true, position: fileLineCol},
+	"helix":         {terminal: true, position: fileLineCol},
+	"kak":           {terminal: true, position: plusLineCol},
+	"emacs":         {terminal: false, position: plusLineCol},
+	"emacsclient":   {terminal: false, position: plusLineCol},
+	"code":          {terminal: false, position: gotoPosition},
+	"code-insiders": {terminal: false, position: gotoPosition},
+	"codium":        {terminal: false, position: gotoPosition},
+	"cursor":        {terminal: false, position: gotoPosition},
+	"subl":          {terminal: false, position: fileLineCol},
+	"zed":           {terminal: false, position: fileLineCol},
+	"gedit":         {terminal: false, position: plusLine},
+}
+
+// emacs flags that keep it in the terminal
+var noWindowFlags = []string{"-nw", "--no-window-system", "-t", "-tty", "--tty"}
+
+// Parse - editor from a config entry or $EDITOR value: a program with optional arguments,
+// e.g. `nvim`, `code --wait` or `myedit --at {line}:{col} {file}`
+func Parse(entry, source string) (Editor, error) {
+	fields := strings.Fields(entry)
+	if len(fields) == 0 {
+		return Editor{}, fmt.Errorf("empty editor")
+	}
+
+	if len(fields) == 1 && strings.EqualFold(fields[0], ViewerName) {
+		return Editor{Name: entry, Source: source, Viewer: true}, nil
+	}
+
+	ed := Editor{
+		Name:     strings.TrimSpace(entry),
+		Program:  fields[0],
+		Args:     fields[1:],
+		Source:   source,
+		Terminal: true,
+	}
+
+	if k, ok := known[programName(ed.Program)]; ok {
+		ed.Terminal = k.terminal
+		ed.position = k.position
+	}
+	for _, arg := range ed.Args {
+		for _, flag := range noWindowFlags {
+			if arg == flag {
+				ed.Terminal = true
+			}
+		}
+	}
+
+	return ed, nil
+}
+
+// Path - full path of the program, the name is retried in lower case since the
+// config lists editors as they are shown, e.g. Code
+func (e Editor) Path() (string, error) {
+	if e.Viewer {
+		return "", nil
+	}
+
+	path, err := exec.LookPath(e.Program)
+	if err != nil && strings.ToLower(e.Program) != e.Program {
+		path, err = exec.LookPath(strings.ToLower(e.Program))
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %s is not on PATH", ErrNotInstalled, e.Program)
+	}
+
+	return path, nil
+}
+
+// Installed - the viewer, or the program is on PATH
+func (e Editor) Installed() bool {
+	_, err := e.Path()
+	return err == nil
+}
+
+// Cmd - command opening file at line and column, 0 opens it at the top
+func (e Editor) Cmd(file string, line, col int) (*exec.Cmd, error) {
+	if e.Viewer {
+		return nil, fmt.Errorf("%s is the built in viewer", e.Name)
+	}
+
+	path, err := e.Path()
+	if err != nil {
+		return nil, err
+	}
+
+	return exec.Command(path, e.arguments(file, line, col)...), nil
+}
+
+func (e Editor) arguments(file string, line, col int) []string {
+	// placeholders put the file and position where the editor wants them
+	if e.hasPlaceholders() {
+		replacer := strings.NewReplacer(
+			"{file}", file,
+			"{line}", strconv.Itoa(max(line, 1)),
+			"{col}", strconv.Itoa(max(col, 1)),
+		)
+
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = replacer.Replace(arg)
+		}
+		return args
+	}
+
+	args := append([]string(nil), e.Args...)
+	if e.position == nil || line < 1 {
+		return append(args, file)
+	}
+	return append(args, e.position(file, line, col)...)
+}
+
+func (e Editor) hasPlaceholders() bool {
+	for _, arg := range e.Args {
+		if strings.Contains(arg, "{file}") {
+			return true
+		}
+	}
+	return false
+}
+
+// programName - program base name as found in known, e.g. /usr/bin/nvim and nvim.exe are nvim
+func programName(program string) string {
+	name := strings.ToLower(filepath.Base(program))
+	return strings.TrimSuffix(name, ".exe")
+}
+
+// vim +42 file, vim "+call cursor(42,7)" file
+func vimPosition(file string, line, col int) []string {
+	if col > 0 {
+		return []string{fmt.Sprintf("+call cursor(%d,%d)", line, col), file}
+	}
+	return plusLine(file, line, col)
+}
+
+// vi +42 file
+func plusLine(file string, line, _ int) []string {
+	return []string{"+" + strconv.Itoa(line), file}
+}
+
+// nano +42,7 file
+func nanoPosition(file string, line, col int) []string {
+	if col > 0 {
+		return []string{fmt.Sprintf("+%d,%d", line, col), file}
+	}
+	return plusLine(file, line, col)
+}
+
+// emacs +42:7 file
+func plusLineCol(file string, line, col int) []string {
+	if col > 0 {
+		return []string{fmt.Sprintf("+%d:%d", line, col), file}
+	}
+	return plusLine(file, line, col)
+}
+
+// hx file:42:7
+func fileLineCol(file string, line, col int) []string {
+	if col > 0 {
+		return []string{fmt.Sprintf("%s:%d:%d", file, line, col)}
+	}
+	return []string{fmt.Sprintf("%s:%d", file, line)}
+}
+
+// code --goto file:42:7
+func gotoPosition(file string, line, col int) []string {
+	return append([]string{"--goto"}, fileLineCol(file, line, col)...)
+}
+
+// envEditors - $VISUAL then $EDITOR, the order they are preferred in
+func envEditors() []Editor {
+	var editors []Editor
+	for _, env := range []struct{ name, source string }{{"VISUAL", SourceVisual}, {"EDITOR", SourceEditor}} {
+		if ed, err := Parse(os.Getenv(env.name), env.source); err == nil {
+			editors = append(editors, ed)
+		}
+	}
+	return editors
+}
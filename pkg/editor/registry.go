@@ -0,0 +1,95 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Registry - editors from the config followed by $VISUAL and $EDITOR
+type Registry struct {
+	editors []Editor
+}
+
+// NewRegistry - editors of the config entries, $VISUAL and $EDITOR are added
+// unless the config already lists the same program
+func NewRegistry(entries []string) (*Registry, error) {
+	r := &Registry{}
+	for _, entry := range entries {
+		ed, err := Parse(entry, SourceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid open.editors entry %q: %w", entry, err)
+		}
+		r.editors = append(r.editors, ed)
+	}
+
+	for _, ed := range envEditors() {
+		if !ed.Viewer && !r.hasProgram(ed.Program) {
+			r.editors = append(r.editors, ed)
+		}
+	}
+
+	return r, nil
+}
+
+// All - every editor, installed or not
+func (r *Registry) All() []Editor {
+	return r.editors
+}
+
+// Available - the viewer and the editors found on PATH
+func (r *Registry) Available() []Editor {
+	var available []Editor
+	for _, ed := range r.editors {
+		if ed.Installed() {
+			available = append(available, ed)
+		}
+	}
+	return available
+}
+
+// Find - editor by name or program, case insensitive
+func (r *Registry) Find(name string) (Editor, error) {
+	name = strings.TrimSpace(name)
+	for _, ed := range r.editors {
+		if strings.EqualFold(ed.Name, name) {
+			return ed, nil
+		}
+	}
+	for _, ed := range r.editors {
+		if !ed.Viewer && strings.EqualFold(programName(ed.Program), programName(name)) {
+			return ed, nil
+		}
+	}
+
+	return Editor{}, fmt.Errorf("%w: %q. available: %s", ErrNotFound, name, strings.Join(Names(r.Available()), ", "))
+}
+
+// Preferred - editor to hand a file to: $VISUAL, $EDITOR, then the first installed one
+func (r *Registry) Preferred() (Editor, bool) {
+	for _, source := range []string{SourceVisual, SourceEditor, SourceConfig} {
+		for _, ed := range r.editors {
+			if ed.Source == source && !ed.Viewer && ed.Installed() {
+				return ed, true
+			}
+		}
+	}
+	return Editor{}, false
+}
+
+// Names - names of editors, in order
+func Names(editors []Editor) []string {
+	names := make([]string, 0, len(editors))
+	for _, ed := range editors {
+		names = append(names, ed.Name)
+	}
+	return names
+}
+
+func (r *Registry) hasProgram(program string) bool {
+	for _, ed := range r.editors {
+		if !ed.Viewer && programName(ed.Program) == programName(program) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout   = 2 * time.Minute
+	DefaultMaxOutput = 1024 * 1024
+)
+
+// DefaultEnv - environment variables passed through to commands, everything else is scrubbed
+var DefaultEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TMPDIR", "TZ",
+	"LANG", "LC_ALL", "LC_CTYPE",
+	"GOPATH", "GOROOT", "GOCACHE", "GOMODCACHE", "GOFLAGS", "GOPROXY", "GOPRIVATE",
+}
+
+// Executor - runs commands in the workspace root under a policy
+type Executor struct {
+	// Dir - working directory, the workspace root
+	Dir string
+
+	Policy Policy
+
+	// Timeout - per command limit, zero uses DefaultTimeout
+	Timeout time.Duration
+
+	// MaxOutput - bytes captured per stream, zero uses DefaultMaxOutput
+	MaxOutput int
+
+	// Env - names of variables passed through, nil uses DefaultEnv
+	Env []string
+}
+
+// Result - outcome of a command
+type Result struct {
+	Command   string        `json:"command"`
+	Stdout    string        `json:"stdout"`
+	Stderr    string        `json:"stderr"`
+	ExitCode  int           `json:"exit_code"`
+	Duration  time.Duration `json:"duration"`
+	TimedOut  bool          `json:"timed_out,omitempty"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+// RunOptions - live output, both writers are optional
+type RunOptions struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// New - executor for a workspace root with the default policy
+func New(dir string) *Executor {
+	return &Executor{
+		Dir:    dir,
+		Policy: Policy{Allow: DefaultAllow, Deny: DefaultDeny, Root: dir},
+	}
+}
+
+// Decide - policy decision for a command, allowed commands are confined to Dir unless the policy has a root
+func (e *Executor) Decide(argv []string) Decision {
+	policy := e.Policy
+	if policy.Root == "" {
+		policy.Root = e.Dir
+	}
+	return policy.Decide(argv)
+}
+
+// Run - run a command, denied commands are rejected before starting.
+// a non zero exit code is not an error, it is reported in the result
+func (e *Executor) Run(ctx context.Context, argv []string, opts RunOptions) (*Result, error) {
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("command cannot be empty")
+	}
+
+	if err := e.Policy.Check(argv); err != nil {
+		return nil, err
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	maxOutput := e.MaxOutput
+	if maxOutput <= 0 {
+		maxOutput = DefaultMaxOutput
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = e.Dir
+	cmd.Env = e.environ()
+	cmd.Stdin = nil
+	cmd.WaitDelay = 2 * time.Second
+	setProcessGroup(cmd)
+
+	stdout := &limitedBuffer{max: maxOutput}
+	stderr := &limitedBuffer{max: maxOutput}
+	cmd.Stdout = teeWriter(stdout, opts.Stdout)
+	cmd.Stderr = teeWriter(stderr, opts.Stderr)
+
+	result := &Result{Command: strings.Join(argv, " ")}
+
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+	result.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut:
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("error running %s: %w", argv[0], err)
+	}
+
+	if result.TimedOut {
+		return result, fmt.Errorf("command timed out after %s", timeout)
+	}
+
+	return result, nil
+}
+
+// environ - scrubbed environment holding only the passed through variables
+func (e *Executor) environ() []string {
+	names := e.Env
+	if names == nil {
+		names = DefaultEnv
+	}
+
+	var env []string
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	return env
+}
+
+// limitedBuffer - keeps the first max bytes and drops the rest
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func teeWriter(capture io.Writer, live io.Writer) io.Writer {
+	if live == nil {
+		return capture
+	}
+	return io.MultiWriter(capture, live)
+}
@@ -0,0 +1,221 @@
+package executor
+
+import (
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"path/filepath"
+	"strings"
+)
+
+// Decision - what the policy says about a command
+type Decision int
+
+const (
+	// NeedsApproval - neither allowed nor denied, the user has to confirm
+	NeedsApproval Decision = iota
+	// Allowed - pre-approved, runs without confirmation
+	Allowed
+	// Denied - never runs
+	Denied
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Allowed:
+		return "allowed"
+	case Denied:
+		return "denied"
+	default:
+		return "needs approval"
+	}
+}
+
+// DefaultAllow - commands that run without confirmation. only commands that read files are
+// listed, anything that builds or runs project code (go test, make), rewrites files (go fmt)
+// or moves refs (git branch -D) has to be confirmed
+var DefaultAllow = []string{
+	"ls", "pwd", "cat", "head", "tail", "wc", "grep", "tree",
+	"git status", "git diff", "git log", "git show",
+	"go vet", "go list", "go version",
+}
+
+// DefaultDeny - commands that never run
+var DefaultDeny = []string{
+	"sudo", "su", "doas", "shutdown", "reboot", "halt", "poweroff",
+	"mkfs", "dd", "chown",
+	"rm -rf", "rm -Rf", "rm -r --force", "rm -R --force", "rm --recursive -f", "rm --recursive --force",
+}
+
+// unsafeArgs - arguments that make an allowed command write files or run programs, a command
+// using one needs confirmation. keyed by program, "*" applies to every program
+var unsafeArgs = map[string][]string{
+	"*": {
+		"-delete", "-exec*", "-ok", "-okdir", "-fprint*", "-fls", "--output*", "--ext-diff", "--textconv",
+		"-vettool*", "--vettool*", "-toolexec*", "--toolexec*",
+	},
+	"tree": {"-o"},
+}
+
+// Policy - allow and deny rules, deny wins over allow.
+// a rule is a command prefix, "git status" matches "git status -s" but not "git push".
+// words are shell globs so "go *" works, and the program is matched on its base name.
+// flags in a rule match in any order and combined, "rm -rf" matches "rm -f -r x" and "rm -fr /".
+// an allowed command still needs confirmation when it writes files, runs programs or names
+// a path outside Root
+type Policy struct {
+	Allow []string
+	Deny  []string
+
+	// Root - directory allowed commands are confined to, the workspace root
+	Root string
+}
+
+// Decide - policy decision for a command
+func (p Policy) Decide(argv []string) Decision {
+	if len(argv) == 0 {
+		return Denied
+	}
+
+	for _, rule := range p.Deny {
+		if matchRule(rule, argv) {
+			return Denied
+		}
+	}
+
+	for _, rule := range p.Allow {
+		if matchRule(rule, argv) {
+			if !p.confined(argv) {
+				return NeedsApproval
+			}
+			return Allowed
+		}
+	}
+
+	return NeedsApproval
+}
+
+// confined - the command neither writes files nor runs programs and every path it names is inside Root
+func (p Policy) confined(argv []string) bool {
+	program := filepath.Base(argv[0])
+	for _, arg := range argv[1:] {
+		for _, key := range []string{"*", program} {
+			for _, unsafe := range unsafeArgs[key] {
+				// a trailing * takes any value, slashes included, unlike filepath.Match
+				if prefix, ok := strings.CutSuffix(unsafe, "*"); (ok && strings.HasPrefix(arg, prefix)) || arg == unsafe {
+					return false
+				}
+			}
+		}
+
+		// the value of --flag=value is checked like an argument of its own
+		if strings.HasPrefix(arg, "-") {
+			_, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				continue
+			}
+			arg = value
+		}
+		if !p.inside(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// inside - an argument taken as a path relative to Root does not leave it, symlinks are followed
+func (p Policy) inside(arg string) bool {
+	if arg == "" {
+		return true
+	}
+	if strings.HasPrefix(arg, "~") || p.Root == "" {
+		return false
+	}
+
+	path := arg
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.Root, path)
+	}
+	ws := &workspace.Workspace{Root: p.Root}
+	_, err := ws.Resolve(path)
+	return err == nil
+}
+
+// Check - error for denied commands, nil otherwise
+func (p Policy) Check(argv []string) error {
+	if p.Decide(argv) == Denied {
+		return &DeniedError{Command: strings.Join(argv, " ")}
+	}
+	return nil
+}
+
+// DeniedError - command rejected by the deny list
+type DeniedError struct {
+	Command string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("command %q is not allowed by the run policy", e.Command)
+}
+
+// matchRule - the program and the leading operands match the words of the rule in order,
+// the flags of the rule are all among the flags of the command wherever they are
+func matchRule(rule string, argv []string) bool {
+	words := strings.Fields(rule)
+	if len(words) == 0 || !matchWord(words[0], filepath.Base(argv[0])) {
+		return false
+	}
+
+	var operands []string
+	flags := map[string]bool{}
+	for _, arg := range argv[1:] {
+		for _, flag := range splitFlags(arg) {
+			flags[flag] = true
+		}
+		if !strings.HasPrefix(arg, "-") {
+			operands = append(operands, arg)
+		}
+	}
+
+	next := 0
+	for _, word := range words[1:] {
+		if strings.HasPrefix(word, "-") {
+			for _, flag := range splitFlags(word) {
+				if !flags[flag] {
+					return false
+				}
+			}
+			continue
+		}
+		if next == len(operands) || !matchWord(word, operands[next]) {
+			return false
+		}
+		next++
+	}
+
+	return true
+}
+
+func matchWord(word, arg string) bool {
+	if word == arg {
+		return true
+	}
+	ok, err := filepath.Match(word, arg)
+	return err == nil && ok
+}
+
+// splitFlags - the flags an argument sets, -rf sets -r and -f, --force=x sets --force
+func splitFlags(arg string) []string {
+	switch {
+	case arg == "-" || arg == "--" || !strings.HasPrefix(arg, "-"):
+		return nil
+	case strings.HasPrefix(arg, "--"):
+		name, _, _ := strings.Cut(arg, "=")
+		return []string{name}
+	}
+
+	flags := make([]string, 0, len(arg)-1)
+	for _, r := range arg[1:] {
+		flags = append(flags, "-"+string(r))
+	}
+	return flags
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyDecide(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(os.TempDir(), filepath.Join(root, "outside")); err != nil {
+		t.Fatal(err)
+	}
+	root, _ = filepath.EvalSymlinks(root)
+
+	policy := Policy{Allow: DefaultAllow, Deny: DefaultDeny, Root: root}
+
+	tests := []struct {
+		command string
+		want    Decision
+	}{
+		{"ls", Allowed},
+		{"ls -la src", Allowed},
+		{"cat src/main.go", Allowed},
+		{"/bin/cat main.go", Allowed},
+		{"git status -s", Allowed},
+		{"git diff --stat HEAD", Allowed},
+		{"grep -rn TODO .", Allowed},
+
+		{"git push", NeedsApproval},
+		{"go test ./...", NeedsApproval},
+		{"go build ./...", NeedsApproval},
+		{"make build", NeedsApproval},
+		{"find . -delete", NeedsApproval},
+		{"cat /etc/passwd", NeedsApproval},
+		{"cat ~/.ssh/id_rsa", NeedsApproval},
+		{"cat ../secret", NeedsApproval},
+		{"cat outside/file", NeedsApproval},
+		{"grep -r key --include=../x .", NeedsApproval},
+		{"git diff --output=/tmp/x", NeedsApproval},
+		{"git diff --output=src/x", NeedsApproval},
+		{"git diff --ext-diff", NeedsApproval},
+		{"tree -o listing.txt", NeedsApproval},
+		{"go fmt ./...", NeedsApproval},
+		{"git branch", NeedsApproval},
+		{"git branch -D main", NeedsApproval},
+		{"git branch -f main HEAD~3", NeedsApproval},
+		{"go vet -vettool=./x", NeedsApproval},
+		{"go vet -vettool ./x ./...", NeedsApproval},
+		{"go vet -toolexec ./x", NeedsApproval},
+		{"go vet --toolexec=./x ./...", NeedsApproval},
+		{"go list -export -toolexec=./x", NeedsApproval},
+		{"go vet ./...", Allowed},
+		{"go list -m all", Allowed},
+
+		{"sudo ls", Denied},
+		{"/usr/bin/dd if=/dev/zero", Denied},
+		{"rm -rf /", Denied},
+		{"rm -fr /", Denied},
+		{"rm -rf /*", Denied},
+		{"rm -r -f build", Denied},
+		{"rm --recursive --force .", Denied},
+		{"rm -R --force x", Denied},
+		{"rm file.txt", NeedsApproval},
+	}
+
+	for _, tt := range tests {
+		argv, err := SplitCommand(tt.command)
+		if err != nil {
+			t.Fatalf("SplitCommand(%q): %v", tt.command, err)
+		}
+		if got := policy.Decide(argv); got != tt.want {
+			t.Errorf("Decide(%q) = %s, want %s", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		command []string
+		want    bool
+	}{
+		{"git status", []string{"git", "status", "-s"}, true},
+		{"git status", []string{"git", "push"}, false},
+		{"go *", []string{"go", "run", "."}, true},
+		{"rm -rf", []string{"rm", "-f", "x", "-r"}, true},
+		{"rm -rf", []string{"rm", "-r", "x"}, false},
+		{"rm --force", []string{"rm", "--force=yes", "x"}, true},
+		{"git diff", []string{"git"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchRule(tt.rule, tt.command); got != tt.want {
+			t.Errorf("matchRule(%q, %q) = %v, want %v", tt.rule, tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package executor
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup - process groups are unix only, the default cancel kills the process
+func setProcessGroup(cmd *exec.Cmd) {}
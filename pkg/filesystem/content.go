@@ -0,0 +1,189 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// text encodings told apart by Sniff
+const (
+	EncodingUTF8    = "UTF-8"
+	EncodingUTF8BOM = "UTF-8 with BOM"
+	EncodingUTF16LE = "UTF-16LE"
+	EncodingUTF16BE = "UTF-16BE"
+	EncodingBinary  = "binary"
+)
+
+// SniffLen - bytes read to detect the content
+const SniffLen = 512 * 16
+
+// ErrBinary - the file is not text
+var ErrBinary = errors.New("binary file")
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// Content - what a file holds, detected from its first bytes
+type Content struct {
+	Size     int64
+	MIME     string
+	Encoding string
+}
+
+// Binary - the content is not text in a known encoding
+func (c Content) Binary() bool {
+	return c.Encoding == EncodingBinary
+}
+
+// BOMLen - length of the byte order mark the file starts with
+func (c Content) BOMLen() int {
+	switch c.Encoding {
+	case EncodingUTF8BOM:
+		return len(bomUTF8)
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return len(bomUTF16LE)
+	}
+	return 0
+}
+
+// Sniff - detect the MIME type and encoding of a file from its first bytes
+func Sniff(path string) (Content, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Content{}, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return Content{}, err
+	}
+
+	sample := make([]byte, SniffLen)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Content{}, err
+	}
+
+	content := DetectContent(sample[:n])
+	content.Size = info.Size()
+	return content, nil
+}
+
+// DetectContent - MIME type and encoding of the first bytes of a file. a byte order mark
+// decides the encoding, otherwise NUL bytes or mostly non printable bytes mean binary
+func DetectContent(sample []byte) Content {
+	content := Content{MIME: http.DetectContentType(sample)}
+
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		content.Encoding = EncodingUTF8BOM
+	case bytes.HasPrefix(sample, bomUTF16LE) && !bytes.HasPrefix(sample, []byte{0xff, 0xfe, 0, 0}):
+		// FF FE 00 00 is UTF-32, left to the binary checks
+		content.Encoding = EncodingUTF16LE
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		content.Encoding = EncodingUTF16BE
+	case looksBinary(sample):
+		content.Encoding = EncodingBinary
+	default:
+		content.Encoding = EncodingUTF8
+	}
+
+	return content
+}
+
+// looksBinary - NUL bytes, or more than one in ten bytes that text does not contain
+// when http.DetectContentType does not already call it text
+func looksBinary(sample []byte) bool {
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	if strings.HasPrefix(http.DetectContentType(sample), "text/") {
+		return false
+	}
+
+	total, suspicious := len(sample), 0
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		switch {
+		case r == utf8.RuneError && size == 1 && len(sample) >= utf8.UTFMax:
+			// an invalid byte, unless it is a rune cut off at the end of the sample
+			suspicious++
+		case r < 0x20 && !strings.ContainsRune("\t\n\r\f\b\x1b", r):
+			suspicious++
+		}
+		sample = sample[size:]
+	}
+
+	return suspicious*10 > total
+}
+
+// ReadText - a text file decoded to UTF-8 without its byte order mark, ErrBinary for anything else
+func ReadText(path string) (string, Content, error) {
+	content, err := Sniff(path)
+	if err != nil {
+		return "", content, err
+	}
+	if content.Binary() {
+		return "", content, fmt.Errorf("%w: %s", ErrBinary, content.MIME)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", content, err
+	}
+
+	return DecodeText(data, content.Encoding), content, nil
+}
+
+// DecodeText - text in one of the detected encodings as UTF-8, without the byte order mark
+func DecodeText(data []byte, encoding string) string {
+	switch encoding {
+	case EncodingUTF8BOM:
+		return string(bytes.TrimPrefix(data, bomUTF8))
+	case EncodingUTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), binary.LittleEndian)
+	case EncodingUTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), binary.BigEndian)
+	}
+	return string(data)
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// FormatSize - size in bytes, KiB, MiB or GiB with one decimal
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TiB", value)
+}
@@ -0,0 +1,172 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrExists - the file to create is already there
+var ErrExists = errors.New("file already exists")
+
+// Plan - what Create writes, or would write in a dry run
+type Plan struct {
+	Path string
+	Dirs []string // missing parent directories, outermost first
+	Size int
+}
+
+// PlanCreate - the directories and file Create would write for path, nothing is touched
+func PlanCreate(path string, size int) (Plan, error) {
+	plan := Plan{Path: path, Size: size}
+
+	_, statErr := os.Lstat(path)
+	if statErr == nil {
+		return plan, fmt.Errorf("%w: %s", ErrExists, path)
+	}
+
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return plan, fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return plan, err
+		}
+
+		plan.Dirs = append([]string{dir}, plan.Dirs...)
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	// e.g. permission denied, a file in place of a parent is reported above
+	if !errors.Is(statErr, fs.ErrNotExist) {
+		return plan, statErr
+	}
+
+	return plan, nil
+}
+
+// Create - write a new file holding content, making missing parent directories. the content goes to
+// a temp file next to path that is linked into place, so a failed write leaves neither a partial file
+// nor the directories made for it and a file that appeared since the plan is never replaced.
+// with dryRun only the plan is returned
+func Create(path string, content []byte, dryRun bool) (Plan, error) {
+	plan, err := PlanCreate(path, len(content))
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	if err := plan.write(content); err != nil {
+		// innermost first, the outer ones are only empty once those are gone
+		for i := len(plan.Dirs) - 1; i >= 0; i-- {
+			_ = os.Remove(plan.Dirs[i])
+		}
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+func (p Plan) write(content []byte) error {
+	for _, dir := range p.Dirs {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return fmt.Errorf("error creating directory: %w", err)
+		}
+	}
+
+	return createFile(p.Path, content, 0644)
+}
+
+// WriteFile - replace the content of path, or create it, through a temp file in the same directory
+// renamed over it, so readers see either the old or the new content and never a part of it
+func WriteFile(path string, content []byte, perm fs.FileMode) error {
+	tempPath, err := writeTemp(path, content, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tempPath)
+	}()
+
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+
+	return nil
+}
+
+// createFile - like WriteFile but a file that appeared at path after it was planned is never
+// replaced. the temp file is hard linked into place, which fails when path exists
+func createFile(path string, content []byte, perm fs.FileMode) error {
+	tempPath, err := writeTemp(path, content, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tempPath)
+	}()
+
+	err = os.Link(tempPath, path)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrExists, path)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// file systems without hard links, the content is written in place to a file that must be new
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrExists, path)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+	if _, err := file.Write(content); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("error closing file: %w", err)
+	}
+	return nil
+}
+
+// writeTemp - content in a synced temp file next to path, the caller removes it
+func writeTemp(path string, content []byte, perm fs.FileMode) (string, error) {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating file: %w", err)
+	}
+	tempPath := temp.Name()
+
+	fail := func(err error) (string, error) {
+		_ = temp.Close()
+		_ = os.Remove(tempPath)
+		return "", err
+	}
+	if _, err := temp.Write(content); err != nil {
+		return fail(fmt.Errorf("error writing to file: %w", err))
+	}
+	if err := temp.Chmod(perm); err != nil {
+		return fail(fmt.Errorf("error writing to file: %w", err))
+	}
+	if err := temp.Sync(); err != nil {
+		return fail(fmt.Errorf("error writing to file: %w", err))
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return "", fmt.Errorf("error closing file: %w", err)
+	}
+
+	return tempPath, nil
+}
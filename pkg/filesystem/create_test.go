@@ -0,0 +1,57 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a", "b", "main.go")
+
+	plan, err := Create(path, []byte("package b\n"), false)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(plan.Dirs) != 2 {
+		t.Errorf("Create() made %v, want 2 directories", plan.Dirs)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "package b\n" {
+		t.Errorf("ReadFile() = %q, %v", data, err)
+	}
+
+	if _, err := Create(path, []byte("other"), false); !errors.Is(err, ErrExists) {
+		t.Errorf("Create() on an existing file error = %v, want %v", err, ErrExists)
+	}
+}
+
+func TestCreateNeverReplaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+
+	// the file appears after the plan said the path was free
+	plan, err := PlanCreate(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("theirs"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := plan.write([]byte("ours")); !errors.Is(err, ErrExists) {
+		t.Fatalf("write() error = %v, want %v", err, ErrExists)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "theirs" {
+		t.Errorf("file was replaced, holds %q", data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
@@ -0,0 +1,35 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+)
+
+// Remove - permanently delete a file, or a directory with all its contents.
+// symlinks are removed themselves, never their target
+func Remove(path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, fmt.Errorf("error accessing path: %w", err)
+	}
+
+	if info.IsDir() {
+		err = os.RemoveAll(path)
+	} else {
+		err = os.Remove(path)
+	}
+
+	if err != nil {
+		return info.IsDir(), fmt.Errorf("deletion failed: %w", err)
+	}
+
+	return info.IsDir(), nil
+}
+
+// ItemType - human name of a path type used in messages
+func ItemType(isDir bool) string {
+	if isDir {
+		return "directory"
+	}
+	return "file"
+}
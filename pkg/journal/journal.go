@@ -0,0 +1,436 @@
+package journal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/filesystem"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/trash"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkspaceDir - journal directory, relative to the workspace root
+var WorkspaceDir = filepath.Join(".agent-code", "journal")
+
+const (
+	entriesDir = "entries"
+	backupsDir = "backups"
+	entryExt   = ".json"
+	maxIDTries = 100
+)
+
+// operations recorded in the journal
+const (
+	OpCreate = "create"
+	OpDelete = "delete"
+	OpEdit   = "edit"
+)
+
+var (
+	// ErrNotFound - no entry has the id
+	ErrNotFound = errors.New("journal entry not found")
+
+	// ErrChanged - the path was changed after the operation, undoing it would lose that change
+	ErrChanged = errors.New("changed since")
+
+	// ErrNotUndoable - the operation left nothing to undo it with, e.g. a permanent delete or an emptied trash
+	ErrNotUndoable = errors.New("cannot be undone")
+
+	// ErrForeign - the entry names a path outside the workspace or a trash it does not use,
+	// entries are plain files in the workspace so they are not trusted to stay as written
+	ErrForeign = errors.New("does not belong to the workspace")
+)
+
+// Journal - every mutation of the workspace, one json file per entry under entries/
+// and the content edits replaced under backups/
+type Journal struct {
+	Dir string
+
+	// Root - workspace root, undo only touches paths below it
+	Root string
+
+	// Session - id of the run recording entries, see NewSession
+	Session string
+}
+
+// Entry - one recorded mutation
+type Entry struct {
+	ID      int       `json:"id"`
+	Op      string    `json:"op"`
+	Path    string    `json:"path"` // absolute
+	IsDir   bool      `json:"is_dir,omitempty"`
+	Time    time.Time `json:"time"`
+	Session string    `json:"session"`
+
+	// Hash - sha256 of the file after a create or edit, undo refuses when it no longer matches
+	Hash string `json:"hash,omitempty"`
+
+	// BeforeHash - sha256 of the file before an edit, its content is kept under backups/<id>
+	BeforeHash string `json:"before_hash,omitempty"`
+
+	// Dirs - parent directories a create made, removed again on undo when empty
+	Dirs []string `json:"dirs,omitempty"`
+
+	// TrashDir and TrashName - where a delete moved the path, empty for permanent deletes
+	TrashDir  string `json:"trash_dir,omitempty"`
+	TrashName string `json:"trash_name,omitempty"`
+
+	UndoneAt *time.Time `json:"undone_at,omitempty"`
+}
+
+// Undone - the entry was rolled back
+func (e Entry) Undone() bool {
+	return e.UndoneAt != nil
+}
+
+// Permanent - a delete that skipped the trash, it is recorded but cannot be undone
+func (e Entry) Permanent() bool {
+	return e.Op == OpDelete && e.TrashName == ""
+}
+
+// ForWorkspace - the journal under .agent-code/journal
+func ForWorkspace(root, session string) *Journal {
+	return &Journal{Dir: filepath.Join(root, WorkspaceDir), Root: root, Session: session}
+}
+
+// Contains - check whether a path is the journal directory or inside it
+func (j *Journal) Contains(path string) bool {
+	rel, err := filepath.Rel(j.Dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// NewSession - random id grouping the entries of one run
+func NewSession() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Hash - sha256 of content as hex
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordCreate - a file was created holding content, dirs are the parents made for it
+func (j *Journal) RecordCreate(path string, content []byte, dirs []string) (*Entry, error) {
+	return j.add(&Entry{Op: OpCreate, Path: path, Hash: Hash(content), Dirs: dirs})
+}
+
+// RecordDelete - a path was moved to a trash, or deleted for good when item is nil
+func (j *Journal) RecordDelete(path string, isDir bool, bin *trash.Trash, item *trash.Item) (*Entry, error) {
+	entry := &Entry{Op: OpDelete, Path: path, IsDir: isDir}
+	if item != nil {
+		entry.TrashDir, entry.TrashName = bin.Dir, item.Name
+	}
+	return j.add(entry)
+}
+
+// RecordEdit - the content of a file was replaced, before is kept so the edit can be undone
+func (j *Journal) RecordEdit(path string, before, after []byte) (*Entry, error) {
+	entry := &Entry{Op: OpEdit, Path: path, Hash: Hash(after), BeforeHash: Hash(before)}
+	return j.add(entry, before)
+}
+
+// List - all entries, newest first
+func (j *Journal) List() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(j.Dir, entriesDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	var entries []Entry
+	for _, dirEntry := range dirEntries {
+		id, err := strconv.Atoi(strings.TrimSuffix(dirEntry.Name(), entryExt))
+		if err != nil || !strings.HasSuffix(dirEntry.Name(), entryExt) {
+			continue
+		}
+
+		entry, err := j.Get(id)
+		if err != nil {
+			// skip malformed entries rather than failing the whole history
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].ID > entries[b].ID
+	})
+
+	return entries, nil
+}
+
+// Get - entry by id
+func (j *Journal) Get(id int) (*Entry, error) {
+	data, err := os.ReadFile(j.entryPath(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid journal entry %d: %w", id, err)
+	}
+	return &entry, nil
+}
+
+// Check - the entry can be undone right now: it is not undone yet, its paths are in the workspace,
+// the operation left what undoing it needs and nothing touched the path since
+func (j *Journal) Check(entry *Entry) error {
+	if entry.Undone() {
+		return fmt.Errorf("entry %d is already undone", entry.ID)
+	}
+	if err := j.confined(entry); err != nil {
+		return err
+	}
+
+	switch entry.Op {
+	case OpCreate, OpEdit:
+		hash, err := HashFile(entry.Path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s no longer exists", ErrChanged, entry.Path)
+		}
+		if err != nil {
+			return err
+		}
+		if hash != entry.Hash {
+			return fmt.Errorf("%w: %s was modified after entry %d", ErrChanged, entry.Path, entry.ID)
+		}
+
+	case OpDelete:
+		if entry.Permanent() {
+			return fmt.Errorf("%w: %s was deleted permanently", ErrNotUndoable, entry.Path)
+		}
+		if _, err := os.Lstat(entry.Path); err == nil {
+			return fmt.Errorf("%w: %s exists again", ErrChanged, entry.Path)
+		}
+		item, err := (&trash.Trash{Dir: entry.TrashDir, Root: j.Root}).Find(entry.TrashName)
+		if err != nil || item.Name != entry.TrashName {
+			return fmt.Errorf("%w: %s is no longer in the trash", ErrNotUndoable, entry.Path)
+		}
+		if item.OriginalPath != entry.Path {
+			return fmt.Errorf("%w: entry %d, trashed item %s was deleted from %s", ErrForeign, entry.ID, item.Name, item.OriginalPath)
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrNotUndoable, entry.Op)
+	}
+
+	return nil
+}
+
+// confined - every path of the entry is inside the workspace and outside the journal, and a delete
+// went to the workspace or home trash
+func (j *Journal) confined(entry *Entry) error {
+	if j.Root == "" {
+		return fmt.Errorf("%w: entry %d, the journal has no workspace root", ErrForeign, entry.ID)
+	}
+	ws := &workspace.Workspace{Root: j.Root}
+
+	for _, path := range append([]string{entry.Path}, entry.Dirs...) {
+		resolved, err := ws.ResolveEntry(path)
+		if err != nil || resolved != filepath.Clean(path) || resolved == j.Root || j.Contains(resolved) {
+			return fmt.Errorf("%w: entry %d names %s", ErrForeign, entry.ID, path)
+		}
+	}
+
+	if entry.Op == OpDelete && !entry.Permanent() {
+		bins := []string{trash.ForWorkspace(j.Root).Dir}
+		if home, err := trash.Home(); err == nil {
+			bins = append(bins, home.Dir)
+		}
+		if !slices.Contains(bins, filepath.Clean(entry.TrashDir)) ||
+			entry.TrashName != filepath.Base(entry.TrashName) || entry.TrashName == ".." {
+			return fmt.Errorf("%w: entry %d names the trash %s", ErrForeign, entry.ID, filepath.Join(entry.TrashDir, entry.TrashName))
+		}
+	}
+
+	return nil
+}
+
+// Undo - roll the entry back after checking it, see Check, and mark it undone
+func (j *Journal) Undo(entry *Entry) error {
+	if err := j.Check(entry); err != nil {
+		return err
+	}
+
+	switch entry.Op {
+	case OpCreate:
+		if err := os.Remove(entry.Path); err != nil {
+			return fmt.Errorf("error removing %s: %w", entry.Path, err)
+		}
+		// innermost first, directories that got other files since stay
+		for i := len(entry.Dirs) - 1; i >= 0; i-- {
+			_ = os.Remove(entry.Dirs[i])
+		}
+
+	case OpDelete:
+		bin := &trash.Trash{Dir: entry.TrashDir, Root: j.Root}
+		if _, err := bin.Restore(entry.TrashName); err != nil {
+			return err
+		}
+
+	case OpEdit:
+		before, err := os.ReadFile(j.backupPath(entry.ID))
+		if err != nil {
+			return fmt.Errorf("%w: backup of entry %d is missing", ErrNotUndoable, entry.ID)
+		}
+		info, err := os.Stat(entry.Path)
+		if err != nil {
+			return err
+		}
+		if err := filesystem.WriteFile(entry.Path, before, info.Mode().Perm()); err != nil {
+			return err
+		}
+		_ = os.Remove(j.backupPath(entry.ID))
+	}
+
+	now := time.Now().Truncate(time.Second)
+	entry.UndoneAt = &now
+	return j.write(entry)
+}
+
+// add - give the entry the next id and store it, with the backup of an edit
+func (j *Journal) add(entry *Entry, backup ...[]byte) (*Entry, error) {
+	if err := j.init(); err != nil {
+		return nil, err
+	}
+
+	entry.Time = time.Now().Truncate(time.Second)
+	entry.Session = j.Session
+
+	id, err := j.reserve()
+	if err != nil {
+		return nil, err
+	}
+	entry.ID = id
+
+	if len(backup) > 0 {
+		if err := os.WriteFile(j.backupPath(id), backup[0], 0600); err != nil {
+			_ = os.Remove(j.entryPath(id))
+			return nil, fmt.Errorf("error writing journal backup: %w", err)
+		}
+	}
+
+	if err := j.write(entry); err != nil {
+		_ = os.Remove(j.entryPath(id))
+		_ = os.Remove(j.backupPath(id))
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// reserve - create the entry file for the next free id, empty until written
+func (j *Journal) reserve() (int, error) {
+	next, err := j.lastID()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < maxIDTries; i++ {
+		next++
+		file, err := os.OpenFile(j.entryPath(next), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error writing journal: %w", err)
+		}
+		_ = file.Close()
+		return next, nil
+	}
+
+	return 0, fmt.Errorf("no free journal id after %d", next)
+}
+
+func (j *Journal) lastID() (int, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(j.Dir, entriesDir))
+	if err != nil {
+		return 0, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	last := 0
+	for _, dirEntry := range dirEntries {
+		if id, err := strconv.Atoi(strings.TrimSuffix(dirEntry.Name(), entryExt)); err == nil && id > last {
+			last = id
+		}
+	}
+	return last, nil
+}
+
+func (j *Journal) write(entry *Entry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := filesystem.WriteFile(j.entryPath(entry.ID), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	return nil
+}
+
+// init - create the journal directories, kept out of version control
+func (j *Journal) init() error {
+	for _, dir := range []string{entriesDir, backupsDir} {
+		if err := os.MkdirAll(filepath.Join(j.Dir, dir), 0700); err != nil {
+			return fmt.Errorf("error creating journal directory: %w", err)
+		}
+	}
+
+	ignore := filepath.Join(j.Dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		if err := os.WriteFile(ignore, []byte("*\n"), 0600); err != nil {
+			return fmt.Errorf("error creating journal directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (j *Journal) entryPath(id int) string {
+	return filepath.Join(j.Dir, entriesDir, strconv.Itoa(id)+entryExt)
+}
+
+func (j *Journal) backupPath(id int) string {
+	return filepath.Join(j.Dir, backupsDir, strconv.Itoa(id))
+}
+
+// HashFile - sha256 of a file's content
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,89 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndoRefusesForeignEntries(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	victim := filepath.Join(outside, "victim.txt")
+	if err := os.WriteFile(victim, []byte("keep me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inside := filepath.Join(root, "main.go")
+	if err := os.WriteFile(inside, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	j := ForWorkspace(root, "test")
+	hash := Hash([]byte("keep me"))
+
+	tests := []struct {
+		name  string
+		entry *Entry
+	}{
+		{"path outside", &Entry{Op: OpCreate, Path: victim, Hash: hash}},
+		{"relative escape", &Entry{Op: OpCreate, Path: filepath.Join(root, "..", filepath.Base(outside), "victim.txt"), Hash: hash}},
+		{"dirs outside", &Entry{Op: OpCreate, Path: inside, Hash: Hash([]byte("package main\n")), Dirs: []string{outside}}},
+		{"journal itself", &Entry{Op: OpCreate, Path: filepath.Join(j.Dir, "entries", "1.json")}},
+		{"foreign trash", &Entry{Op: OpDelete, Path: filepath.Join(root, "gone.txt"), TrashDir: outside, TrashName: "victim.txt"}},
+		{"trash name escape", &Entry{Op: OpDelete, Path: filepath.Join(root, "gone.txt"), TrashDir: filepath.Join(root, ".agent-code", "trash"), TrashName: "../../x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorded, err := j.add(tt.entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := j.Undo(recorded); !errors.Is(err, ErrForeign) {
+				t.Fatalf("Undo() error = %v, want %v", err, ErrForeign)
+			}
+		})
+	}
+
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("file outside the workspace was touched: %v", err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("directory outside the workspace was removed: %v", err)
+	}
+}
+
+func TestUndoCreate(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "pkg")
+	path := filepath.Join(dir, "new.go")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("package pkg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	j := ForWorkspace(root, "test")
+	entry, err := j.RecordCreate(path, []byte("package pkg\n"), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Undo(entry); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("created directory still exists: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Fake - in-process provider replaying scripted responses, used by tests and
+// to try the commands without a model server. Once the script runs out it
+// echoes the last user message back
+type Fake struct {
+	mu        sync.Mutex
+	responses []Response
+	requests  []Request
+}
+
+// NewFake - create a fake provider answering with the given responses in order
+func NewFake(responses ...Response) *Fake {
+	return &Fake{responses: responses}
+}
+
+// Push - queue more scripted responses
+func (f *Fake) Push(responses ...Response) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses = append(f.responses, responses...)
+}
+
+// Requests - every request received so far
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Request(nil), f.requests...)
+}
+
+// Complete implements Provider
+func (f *Fake) Complete(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resp := f.next(req)
+	return &resp, nil
+}
+
+// Stream implements Provider, content is streamed word by word
+func (f *Fake) Stream(ctx context.Context, req Request) (Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resp := f.next(req)
+
+	var chunks []Chunk
+	for _, word := range strings.SplitAfter(resp.Message.Content, " ") {
+		if word != "" {
+			chunks = append(chunks, Chunk{Content: word})
+		}
+	}
+
+	for i, call := range resp.Message.ToolCalls {
+		chunks = append(chunks, Chunk{ToolCalls: []ToolCallDelta{{
+			Index:     i,
+			ID:        call.ID,
+			Name:      call.Name,
+			Arguments: call.Arguments,
+		}}})
+	}
+
+	usage := resp.Usage
+	chunks = append(chunks, Chunk{FinishReason: resp.FinishReason, Usage: &usage})
+
+	return &fakeStream{ctx: ctx, chunks: chunks}, nil
+}
+
+func (f *Fake) next(req Request) Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, req)
+
+	if len(f.responses) > 0 {
+		resp := f.responses[0]
+		f.responses = f.responses[1:]
+
+		resp.Message.Role = RoleAssistant
+		if resp.FinishReason == "" {
+			resp.FinishReason = "stop"
+			if len(resp.Message.ToolCalls) > 0 {
+				resp.FinishReason = "tool_calls"
+			}
+		}
+		return resp
+	}
+
+	// echo the last user message
+	var last string
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == RoleUser {
+			last = req.Messages[i].Content
+			break
+		}
+	}
+
+	return Response{
+		Message:      Message{Role: RoleAssistant, Content: last},
+		FinishReason: "stop",
+		Usage:        Usage{PromptTokens: len(strings.Fields(last)), CompletionTokens: len(strings.Fields(last)), TotalTokens: 2 * len(strings.Fields(last))},
+	}
+}
+
+type fakeStream struct {
+	ctx    context.Context
+	chunks []Chunk
+}
+
+// Recv implements Stream
+func (s *fakeStream) Recv() (Chunk, error) {
+	if err := s.ctx.Err(); err != nil {
+		return Chunk{}, err
+	}
+
+	if len(s.chunks) == 0 {
+		return Chunk{}, io.EOF
+	}
+
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+// Close implements Stream
+func (s *fakeStream) Close() error {
+	s.chunks = nil
+	return nil
+}
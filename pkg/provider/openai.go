@@ -0,0 +1,353 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// dialTimeout - longest wait for a connection, a shorter Config.Timeout wins
+const dialTimeout = 30 * time.Second
+
+// OpenAI - client for OpenAI-compatible /v1/chat/completions endpoints,
+// works with llama.cpp, Ollama and other local servers speaking the same API
+type OpenAI struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+// APIError - non 2xx response from the endpoint
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("model request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// NewOpenAI - create an OpenAI-compatible client. the timeout covers connecting and waiting for
+// the response headers, not reading the answer, so a long stream is not cut off half way.
+// the request context bounds the rest
+func NewOpenAI(cfg Config) *OpenAI {
+	cfg = cfg.WithDefaults()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = cfg.Timeout
+	transport.DialContext = (&net.Dialer{Timeout: min(cfg.Timeout, dialTimeout), KeepAlive: 30 * time.Second}).DialContext
+
+	return &OpenAI{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		model:   cfg.Model,
+		apiKey:  cfg.APIKey,
+		client:  &http.Client{Transport: transport},
+	}
+}
+
+// wire format
+type openAIFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Arguments   *string         `json:"arguments,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    *int           `json:"index,omitempty"`
+	ID       string         `json:"id,omitempty"`
+	Type     string         `json:"type,omitempty"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIMessage struct {
+	Role       Role             `json:"role,omitempty"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIRequest struct {
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	Tools         []openAITool         `json:"tools,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIChoice struct {
+	Message      openAIMessage `json:"message"`
+	Delta        openAIMessage `json:"delta"`
+	FinishReason *string       `json:"finish_reason"`
+}
+
+type openAIResponse struct {
+	Choices []openAIChoice `json:"choices"`
+	Usage   *Usage         `json:"usage"`
+}
+
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete implements Provider
+func (o *OpenAI) Complete(ctx context.Context, req Request) (*Response, error) {
+	httpResp, err := o.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = httpResp.Body.Close()
+	}()
+
+	var body openAIResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error decoding model response: %w", err)
+	}
+
+	if len(body.Choices) == 0 {
+		return nil, fmt.Errorf("model response has no choices")
+	}
+
+	choice := body.Choices[0]
+	resp := &Response{Message: fromOpenAIMessage(choice.Message)}
+	resp.Message.Role = RoleAssistant
+	if choice.FinishReason != nil {
+		resp.FinishReason = *choice.FinishReason
+	}
+	if body.Usage != nil {
+		resp.Usage = *body.Usage
+	}
+
+	return resp, nil
+}
+
+// Stream implements Provider
+func (o *OpenAI) Stream(ctx context.Context, req Request) (Stream, error) {
+	httpResp, err := o.do(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &openAIStream{body: httpResp.Body, reader: bufio.NewReader(httpResp.Body)}, nil
+}
+
+// do - send the chat completion request, non 2xx responses are turned into an APIError
+func (o *OpenAI) do(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	model := req.Model
+	if model == "" {
+		model = o.model
+	}
+
+	payload := openAIRequest{
+		Model:       model,
+		Messages:    make([]openAIMessage, 0, len(req.Messages)),
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+	}
+	if stream {
+		payload.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	for _, msg := range req.Messages {
+		payload.Messages = append(payload.Messages, toOpenAIMessage(msg))
+	}
+
+	for _, tool := range req.Tools {
+		payload.Tools = append(payload.Tools, openAITool{
+			Type: "function",
+			Function: openAIFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding model request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error creating model request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	httpResp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error sending model request: %w", err)
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		defer func() {
+			_ = httpResp.Body.Close()
+		}()
+
+		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, 64*1024))
+
+		apiErr := &APIError{StatusCode: httpResp.StatusCode, Message: strings.TrimSpace(string(body))}
+
+		var errBody openAIError
+		if json.Unmarshal(body, &errBody) == nil && errBody.Error.Message != "" {
+			apiErr.Message = errBody.Error.Message
+		}
+
+		return nil, apiErr
+	}
+
+	return httpResp, nil
+}
+
+// openAIStream - server sent events stream of chat completion chunks
+type openAIStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	done   bool
+}
+
+// Recv implements Stream
+func (s *openAIStream) Recv() (Chunk, error) {
+	for {
+		if s.done {
+			return Chunk{}, io.EOF
+		}
+
+		line, err := s.reader.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			if errors.Is(err, io.EOF) {
+				s.done = true
+			}
+			return Chunk{}, err
+		}
+
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "data:") {
+			// blank separators, comments and event names
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			s.done = true
+			return Chunk{}, io.EOF
+		}
+
+		var body openAIResponse
+		if err := json.Unmarshal([]byte(data), &body); err != nil {
+			return Chunk{}, fmt.Errorf("error decoding model stream: %w", err)
+		}
+
+		chunk := Chunk{Usage: body.Usage}
+		if len(body.Choices) > 0 {
+			choice := body.Choices[0]
+			if choice.Delta.Content != nil {
+				chunk.Content = *choice.Delta.Content
+			}
+			if choice.FinishReason != nil {
+				chunk.FinishReason = *choice.FinishReason
+			}
+
+			for i, call := range choice.Delta.ToolCalls {
+				delta := ToolCallDelta{Index: i, ID: call.ID, Name: call.Function.Name}
+				if call.Index != nil {
+					delta.Index = *call.Index
+				}
+				if call.Function.Arguments != nil {
+					delta.Arguments = *call.Function.Arguments
+				}
+				chunk.ToolCalls = append(chunk.ToolCalls, delta)
+			}
+		}
+
+		return chunk, nil
+	}
+}
+
+// Close implements Stream
+func (s *openAIStream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+func toOpenAIMessage(msg Message) openAIMessage {
+	content := msg.Content
+	out := openAIMessage{
+		Role:       msg.Role,
+		Content:    &content,
+		ToolCallID: msg.ToolCallID,
+		Name:       msg.Name,
+	}
+
+	// assistant messages that only call tools carry a null content
+	if msg.Role == RoleAssistant && content == "" && len(msg.ToolCalls) > 0 {
+		out.Content = nil
+	}
+
+	for _, call := range msg.ToolCalls {
+		args := call.Arguments
+		out.ToolCalls = append(out.ToolCalls, openAIToolCall{
+			ID:   call.ID,
+			Type: "function",
+			Function: openAIFunction{
+				Name:      call.Name,
+				Arguments: &args,
+			},
+		})
+	}
+
+	return out
+}
+
+func fromOpenAIMessage(msg openAIMessage) Message {
+	out := Message{
+		Role:       msg.Role,
+		ToolCallID: msg.ToolCallID,
+		Name:       msg.Name,
+	}
+	if msg.Content != nil {
+		out.Content = *msg.Content
+	}
+
+	for _, call := range msg.ToolCalls {
+		toolCall := ToolCall{ID: call.ID, Name: call.Function.Name}
+		if call.Function.Arguments != nil {
+			toolCall.Arguments = *call.Function.Arguments
+		}
+		out.ToolCalls = append(out.ToolCalls, toolCall)
+	}
+
+	return out
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenAIStreamOutlivesTimeout(t *testing.T) {
+	words := []string{"a ", "slow ", "answer"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, word := range words {
+			w.(http.Flusher).Flush()
+			time.Sleep(60 * time.Millisecond)
+			_, _ = fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewOpenAI(Config{BaseURL: server.URL, Timeout: 100 * time.Millisecond})
+	stream, err := client.Stream(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v after %q", err, content.String())
+		}
+		content.WriteString(chunk.Content)
+	}
+	if content.String() != strings.Join(words, "") {
+		t.Errorf("streamed %q, want %q", content.String(), strings.Join(words, ""))
+	}
+}
+
+func TestOpenAIHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewOpenAI(Config{BaseURL: server.URL, Timeout: 50 * time.Millisecond})
+	_, err := client.Complete(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err == nil {
+		t.Fatal("Complete() succeeded, want a timeout waiting for the headers")
+	}
+}
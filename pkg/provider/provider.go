@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Role - author of a chat message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message - a single chat message
+type Message struct {
+	Role       Role       `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+// ToolCall - a tool invocation requested by the model, arguments are raw JSON text
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Tool - a tool the model may call, parameters is a JSON Schema object
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// Usage - token accounting reported by the model
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Request - chat completion request
+type Request struct {
+	Model       string
+	Messages    []Message
+	Tools       []Tool
+	Temperature *float64
+	MaxTokens   int
+}
+
+// Response - complete chat completion result
+type Response struct {
+	Message      Message
+	FinishReason string
+	Usage        Usage
+}
+
+// ToolCallDelta - partial tool call streamed by the model, merged by Index
+type ToolCallDelta struct {
+	Index     int
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// Chunk - one streamed piece of a completion
+type Chunk struct {
+	Content      string
+	ToolCalls    []ToolCallDelta
+	FinishReason string
+	Usage        *Usage
+}
+
+// Stream - streamed completion, Recv returns io.EOF once the model is done
+type Stream interface {
+	Recv() (Chunk, error)
+	Close() error
+}
+
+// Provider - chat completion backend
+type Provider interface {
+	// Complete - send the request and wait for the whole response
+	Complete(ctx context.Context, req Request) (*Response, error)
+
+	// Stream - send the request and receive the response in chunks
+	Stream(ctx context.Context, req Request) (Stream, error)
+}
+
+// provider names
+const (
+	NameOpenAI = "openai"
+	NameFake   = "fake"
+)
+
+// environment variables read by ConfigFromEnv
+const (
+	ProviderEnv = "AGENT_CODE_PROVIDER"
+	BaseURLEnv  = "AGENT_CODE_BASE_URL"
+	ModelEnv    = "AGENT_CODE_MODEL"
+	APIKeyEnv   = "AGENT_CODE_API_KEY"
+)
+
+const (
+	DefaultBaseURL = "https://api.openai.com/v1"
+	DefaultModel   = "gpt-4o-mini"
+	DefaultTimeout = 5 * time.Minute
+)
+
+// ErrNotConfigured - provider cannot be built from the given config
+var ErrNotConfigured = errors.New("model provider is not configured")
+
+// ErrInvalidStream - the streamed response cannot be put together, e.g. a tool call index out of order
+var ErrInvalidStream = errors.New("invalid model stream")
+
+// Config - settings used to build a provider
+type Config struct {
+	Provider string
+	BaseURL  string
+	Model    string
+	APIKey   string
+
+	// Timeout - wait for the response headers, a streamed answer may take longer
+	Timeout time.Duration
+}
+
+// ConfigFromEnv - provider config from AGENT_CODE_* variables, falling back to
+// the OPENAI_* ones so an existing OpenAI-compatible setup works as is
+func ConfigFromEnv() Config {
+	return Config{
+		Provider: firstEnv(ProviderEnv),
+		BaseURL:  firstEnv(BaseURLEnv, "OPENAI_BASE_URL"),
+		Model:    firstEnv(ModelEnv, "OPENAI_MODEL"),
+		APIKey:   firstEnv(APIKeyEnv, "OPENAI_API_KEY"),
+	}
+}
+
+// WithDefaults - fill in empty fields
+func (c Config) WithDefaults() Config {
+	if c.Provider == "" {
+		c.Provider = NameOpenAI
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = DefaultBaseURL
+	}
+	if c.Model == "" {
+		c.Model = DefaultModel
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+	return c
+}
+
+// New - build the provider named in the config
+func New(cfg Config) (Provider, error) {
+	cfg = cfg.WithDefaults()
+
+	switch strings.ToLower(cfg.Provider) {
+	case NameOpenAI:
+		return NewOpenAI(cfg), nil
+	case NameFake:
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrNotConfigured, cfg.Provider)
+	}
+}
+
+// Collect - drain a stream into a complete response, calling onChunk for every chunk received
+func Collect(stream Stream, onChunk func(Chunk)) (*Response, error) {
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	var content strings.Builder
+	var calls []ToolCall
+	resp := &Response{}
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+
+		content.WriteString(chunk.Content)
+
+		// merge tool call fragments by their index, a new call takes the next one
+		for _, delta := range chunk.ToolCalls {
+			if delta.Index < 0 || delta.Index > len(calls) {
+				return nil, fmt.Errorf("%w: tool call index %d after %d calls", ErrInvalidStream, delta.Index, len(calls))
+			}
+			if delta.Index == len(calls) {
+				calls = append(calls, ToolCall{})
+			}
+			if delta.ID != "" {
+				calls[delta.Index].ID = delta.ID
+			}
+			calls[delta.Index].Name += delta.Name
+			calls[delta.Index].Arguments += delta.Arguments
+		}
+
+		if chunk.FinishReason != "" {
+			resp.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+	}
+
+	resp.Message = Message{
+		Role:      RoleAssistant,
+		Content:   content.String(),
+		ToolCalls: calls,
+	}
+
+	return resp, nil
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
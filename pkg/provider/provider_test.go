@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// chunkStream - a stream of the given chunks
+type chunkStream struct {
+	chunks []Chunk
+}
+
+func (s *chunkStream) Recv() (Chunk, error) {
+	if len(s.chunks) == 0 {
+		return Chunk{}, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *chunkStream) Close() error {
+	return nil
+}
+
+func TestCollectToolCalls(t *testing.T) {
+	stream := &chunkStream{chunks: []Chunk{
+		{ToolCalls: []ToolCallDelta{{Index: 0, ID: "a", Name: "read_", Arguments: `{"pa`}}},
+		{ToolCalls: []ToolCallDelta{{Index: 0, Name: "file", Arguments: `th":"x"}`}, {Index: 1, ID: "b", Name: "echo"}}},
+		{ToolCalls: []ToolCallDelta{{Index: 1, Arguments: `{}`}}, FinishReason: "tool_calls"},
+	}}
+
+	resp, err := Collect(stream, nil)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	want := []ToolCall{{ID: "a", Name: "read_file", Arguments: `{"path":"x"}`}, {ID: "b", Name: "echo", Arguments: `{}`}}
+	if len(resp.Message.ToolCalls) != len(want) {
+		t.Fatalf("Collect() tool calls = %+v, want %+v", resp.Message.ToolCalls, want)
+	}
+	for i, call := range resp.Message.ToolCalls {
+		if call != want[i] {
+			t.Errorf("tool call %d = %+v, want %+v", i, call, want[i])
+		}
+	}
+	if resp.FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q, want tool_calls", resp.FinishReason)
+	}
+}
+
+func TestCollectInvalidToolCallIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []Chunk
+	}{
+		{"negative", []Chunk{{ToolCalls: []ToolCallDelta{{Index: -1, Name: "x"}}}}},
+		{"skips an index", []Chunk{{ToolCalls: []ToolCallDelta{{Index: 0, Name: "x"}}}, {ToolCalls: []ToolCallDelta{{Index: 2, Name: "y"}}}}},
+		{"huge", []Chunk{{ToolCalls: []ToolCallDelta{{Index: math.MaxInt32, Name: "x"}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Collect(&chunkStream{chunks: tt.chunks}, nil); !errors.Is(err, ErrInvalidStream) {
+				t.Errorf("Collect() error = %v, want %v", err, ErrInvalidStream)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package templates
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TestName - template picked for _test.go files when no template is given
+const TestName = "test"
+
+// DefaultFor - template used for a file when none is picked
+func DefaultFor(file string) string {
+	if strings.HasSuffix(file, "_test.go") {
+		return TestName
+	}
+	return DefaultName
+}
+
+// GoPackage - package clause shared by the .go files of dir. the most common clause of the
+// non test files wins, so a stray `package main` generator does not decide it.
+// falls back to the external test package without _test, empty when dir has no .go files
+func GoPackage(dir string) string {
+	counts := make(map[string]int)
+	var testPkg string
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err != nil || file.Name == nil {
+			continue
+		}
+
+		if strings.HasSuffix(name, "_test.go") {
+			if testPkg == "" {
+				testPkg = strings.TrimSuffix(file.Name.Name, "_test")
+			}
+			continue
+		}
+		counts[file.Name.Name]++
+	}
+
+	if len(counts) > 0 {
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if counts[names[i]] != counts[names[j]] {
+				return counts[names[i]] > counts[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		return names[0]
+	}
+
+	return testPkg
+}
+
+// GoModule - module path and directory of the go.mod governing dir, searched upwards but not above stop
+func GoModule(dir, stop string) (string, string) {
+	for {
+		if path := modulePath(filepath.Join(dir, "go.mod")); path != "" {
+			return path, dir
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == stop || parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// modulePath - module directive of a go.mod file, empty when missing
+func modulePath(goMod string) string {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		rest, ok := strings.CutPrefix(line, "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t' && rest[0] != '"') {
+			continue
+		}
+
+		rest = strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(rest); err == nil {
+			return unquoted
+		}
+		return rest
+	}
+
+	return ""
+}
+
+// GoSubject - an exported function of the package in dir for the test file to call, taken from the
+// file the test is named after (db.go for db_test.go) before the other files, empty when there is none
+func GoSubject(dir, testFile string) string {
+	entries, _ := os.ReadDir(dir)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			names = append(names, name)
+		}
+	}
+
+	own := strings.TrimSuffix(testFile, "_test.go") + ".go"
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[i] == own && names[j] != own
+	})
+
+	for _, name := range names {
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.IsExported() {
+				return fn.Name.Name
+			}
+		}
+	}
+
+	return ""
+}
+
+// goData - fill in the Go specific variables of a file in dir, without sibling files
+// main.go is a main package and anything else is named after its directory
+func goData(data *Data, root, dir string) {
+	if pkg := GoPackage(dir); pkg != "" {
+		data.Package = pkg
+	} else if data.File == "main.go" {
+		data.Package = "main"
+	}
+
+	module, moduleDir := GoModule(dir, root)
+	if module == "" {
+		return
+	}
+
+	data.Module = module
+	data.ImportPath = module
+	if rel, err := filepath.Rel(moduleDir, dir); err == nil && rel != "." {
+		data.ImportPath = module + "/" + filepath.ToSlash(rel)
+	}
+
+	// a main package cannot be imported, its tests stay without the package under test
+	if strings.HasSuffix(data.File, "_test.go") && data.Package != "main" {
+		data.Subject = GoSubject(dir, data.File)
+	}
+}
@@ -0,0 +1,192 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles - files below root with their content, directories made as needed
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGoPackage(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"sibling file", map[string]string{"db.go": "package store\n"}, "store"},
+		{"most common clause", map[string]string{"a.go": "package store\n", "b.go": "package store\n", "gen.go": "package main\n"}, "store"},
+		{"tie broken by name", map[string]string{"a.go": "package zeta\n", "b.go": "package alpha\n"}, "alpha"},
+		{"external test package", map[string]string{"db_test.go": "package store_test\n"}, "store"},
+		{"internal test package", map[string]string{"db_test.go": "package store\n"}, "store"},
+		{"non test files first", map[string]string{"db.go": "package store\n", "db_test.go": "package other_test\n"}, "store"},
+		{"comments before the clause", map[string]string{"doc.go": "// Package store - storage\n\n//go:build linux\n\npackage store\n"}, "store"},
+		{"unparsable file skipped", map[string]string{"bad.go": "packge store\n", "ok.go": "package good\n"}, "good"},
+		{"other files ignored", map[string]string{"README.md": "package nope\n", "sub/x.go": "package sub\n"}, ""},
+		{"empty directory", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			if got := GoPackage(dir); got != tt.want {
+				t.Errorf("GoPackage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := GoPackage(filepath.Join(t.TempDir(), "missing")); got != "" {
+		t.Errorf("GoPackage() of a missing directory = %q, want empty", got)
+	}
+}
+
+func TestGoModule(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                "// the workspace module\nmodule example.com/app // trailing comment\n\ngo 1.24\n",
+		"a/b/x.go":              "package b\n",
+		"tools/go.mod":          "module \"example.com/app/tools\"\n",
+		"tools/cmd/gen/main.go": "package main\n",
+		"broken/go.mod":         "go 1.24\nmodules example.com/nope\n",
+		"broken/x/y.go":         "package x\n",
+	})
+
+	tests := []struct {
+		name    string
+		dir     string
+		stop    string
+		want    string
+		wantDir string // relative to root, empty when nothing is found
+	}{
+		{"module root", ".", ".", "example.com/app", "."},
+		{"nested directory", "a/b", ".", "example.com/app", "."},
+		{"nearest go.mod wins", "tools/cmd/gen", ".", "example.com/app/tools", "tools"},
+		{"go.mod without a module directive skipped", "broken/x", ".", "example.com/app", "."},
+		{"not above stop", "a/b", "a", "", ""},
+		{"stop itself is searched", "tools/cmd", "tools", "example.com/app/tools", "tools"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module, dir := GoModule(filepath.Join(root, tt.dir), filepath.Join(root, tt.stop))
+			wantDir := ""
+			if tt.wantDir != "" {
+				wantDir = filepath.Join(root, tt.wantDir)
+			}
+			if module != tt.want || dir != wantDir {
+				t.Errorf("GoModule() = %q, %q, want %q, %q", module, dir, tt.want, wantDir)
+			}
+		})
+	}
+}
+
+func TestNewDataGo(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                 "module example.com/app\n",
+		"internal/db/db.go":      "package store\n\nfunc helper() {}\n\nfunc (s *Store) Close() {}\n\ntype Store struct{}\n",
+		"internal/db/open.go":    "package store\n\nfunc Open() {}\n",
+		"internal/db/pool.go":    "package store\n\nfunc NewPool() {}\n",
+		"cmd/server/main.go":     "package main\n\nfunc Run() {}\n",
+		"Web-API/handlers/.keep": "",
+	})
+
+	tests := []struct {
+		path       string
+		pkg        string
+		importPath string
+		subject    string
+	}{
+		// the sibling files name the package, not the directory
+		{"internal/db/conn.go", "store", "example.com/app/internal/db", ""},
+		// the test calls a function of the file it is named after before the others
+		{"internal/db/pool_test.go", "store", "example.com/app/internal/db", "NewPool"},
+		{"internal/db/db_test.go", "store", "example.com/app/internal/db", "Open"},
+		// without siblings the directory names the package
+		{"Web-API/handlers/users.go", "handlers", "example.com/app/Web-API/handlers", ""},
+		{"Web-API/api.go", "webapi", "example.com/app/Web-API", ""},
+		{"tools/main.go", "main", "example.com/app/tools", ""},
+		{"main.go", "main", "example.com/app", ""},
+		// a main package cannot be imported by its tests
+		{"cmd/server/main_test.go", "main", "example.com/app/cmd/server", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			data := NewData(root, filepath.FromSlash(tt.path))
+			if data.Package != tt.pkg || data.ImportPath != tt.importPath || data.Subject != tt.subject {
+				t.Errorf("NewData() = package %q, import path %q, subject %q, want %q, %q, %q",
+					data.Package, data.ImportPath, data.Subject, tt.pkg, tt.importPath, tt.subject)
+			}
+			if data.Module != "example.com/app" {
+				t.Errorf("NewData() module = %q, want example.com/app", data.Module)
+			}
+		})
+	}
+
+	// outside a module there is no import path nor subject
+	outside := t.TempDir()
+	writeFiles(t, outside, map[string]string{"lib/lib.go": "package lib\n\nfunc Do() {}\n"})
+	if data := NewData(outside, filepath.Join("lib", "lib_test.go")); data.Package != "lib" || data.Module != "" || data.ImportPath != "" || data.Subject != "" {
+		t.Errorf("NewData() outside a module = %+v, want package lib only", data)
+	}
+}
+
+func TestRenderGoTest(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":             "module example.com/app\n",
+		"internal/db/db.go":  "package store\n\nfunc Open() {}\n",
+		"util/util.go":       "package util\n\nfunc Trim() {}\n",
+		"scratch/.gitignore": "",
+	})
+
+	set, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := set.Find(DefaultFor("db_test.go"), ".go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		// the import is named when the package name differs from the last import path element
+		{"internal/db/db_test.go", []string{"package store_test\n", `store "example.com/app/internal/db"`, "func TestOpen(t *testing.T) {", "_ = store.Open"}},
+		{"util/util_test.go", []string{"package util_test\n", "\t\"example.com/app/util\"\n", "func TestTrim(t *testing.T) {", "_ = util.Trim"}},
+		// nothing to call, the test is named after the file and imports only testing
+		{"scratch/parse_test.go", []string{"package scratch_test\n\nimport (\n\t\"testing\"\n)\n", "func TestParse(t *testing.T) {\n}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			out, err := tmpl.Render(NewData(root, filepath.FromSlash(tt.path)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("Render() =\n%s\nwant it to contain %q", out, want)
+				}
+			}
+		})
+	}
+}
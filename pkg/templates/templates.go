@@ -0,0 +1,316 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// DefaultName - template used when none is picked
+const DefaultName = "default"
+
+// file suffix of a template, e.g. http-handler.go.tmpl is the http-handler template for .go files
+const fileSuffix = ".tmpl"
+
+// sources a template can come from, later ones override earlier ones
+const (
+	SourceBuiltin = "builtin"
+	SourceUser    = "user"
+	SourceProject = "project"
+)
+
+// ErrNotFound - no template with that name for the extension
+var ErrNotFound = errors.New("template not found")
+
+//go:embed builtin/*.tmpl
+var builtinFS embed.FS
+
+// Template - a file template for one extension
+type Template struct {
+	Name   string
+	Ext    string
+	Source string
+	Path   string
+
+	fsys fs.FS
+}
+
+// Data - variables available inside a template
+type Data struct {
+	Name    string // file base name without the extension
+	File    string // file name with the extension
+	Dir     string // directory relative to the workspace root
+	Package string // package name inferred from the directory, or the sibling files for Go
+
+	// Go files only
+	Module     string // module path from go.mod
+	ImportPath string // import path of the file's package
+	Subject    string // exported function of the package under test, _test.go files in a module only
+
+	Author string
+	Date   string
+	Year   int
+}
+
+// Dir - directory templates are loaded from
+type Dir struct {
+	Source string
+	Path   string
+}
+
+// Set - templates by extension and name
+type Set struct {
+	templates map[string]*Template
+}
+
+// DefaultDirs - user templates in $XDG_CONFIG_HOME/agent-code/templates and project ones in .agent-code/templates
+func DefaultDirs(root string) []Dir {
+	var dirs []Dir
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, Dir{Source: SourceUser, Path: filepath.Join(configDir, "agent-code", "templates")})
+	}
+
+	return append(dirs, Dir{Source: SourceProject, Path: filepath.Join(root, ".agent-code", "templates")})
+}
+
+// Load - built in templates overridden by the ones found in dirs, missing dirs are skipped
+func Load(dirs ...Dir) (*Set, error) {
+	set := &Set{templates: make(map[string]*Template)}
+
+	builtin, err := fs.Sub(builtinFS, "builtin")
+	if err != nil {
+		return nil, err
+	}
+	if err := set.add(builtin, SourceBuiltin, "builtin"); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir.Path); os.IsNotExist(err) {
+			continue
+		}
+		if err := set.add(os.DirFS(dir.Path), dir.Source, dir.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+// Find - template by name for an extension
+func (s *Set) Find(name, ext string) (*Template, error) {
+	if name == "" {
+		name = DefaultName
+	}
+
+	if tmpl, ok := s.templates[key(name, ext)]; ok {
+		return tmpl, nil
+	}
+
+	names := s.Names(ext)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: there are no templates for %s files", ErrNotFound, ext)
+	}
+	return nil, fmt.Errorf("%w: %q for %s files. available: %s", ErrNotFound, name, ext, strings.Join(names, ", "))
+}
+
+// Names - template names of an extension, sorted
+func (s *Set) Names(ext string) []string {
+	var names []string
+	for _, tmpl := range s.templates {
+		if tmpl.Ext == ext {
+			names = append(names, tmpl.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List - every template, sorted by extension then name
+func (s *Set) List() []*Template {
+	list := make([]*Template, 0, len(s.templates))
+	for _, tmpl := range s.templates {
+		list = append(list, tmpl)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Ext != list[j].Ext {
+			return list[i].Ext < list[j].Ext
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// Render - execute the template, missing variables are an error
+func (t *Template) Render(data Data) (string, error) {
+	body, err := fs.ReadFile(t.fsys, t.Name+t.Ext+fileSuffix)
+	if err != nil {
+		return "", fmt.Errorf("error reading template %s: %w", t.Path, err)
+	}
+
+	tmpl, err := template.New(t.Name + t.Ext).Funcs(funcs).Option("missingkey=error").Parse(string(body))
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s: %w", t.Path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error rendering template %s: %w", t.Path, err)
+	}
+
+	// Go output is gofmt'ed, left as is when it does not parse
+	if t.Ext == ".go" {
+		if formatted, err := format.Source(buf.Bytes()); err == nil {
+			return string(formatted), nil
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// NewData - variables for a file, path is relative to the workspace root
+func NewData(root, path string) Data {
+	file := filepath.Base(path)
+	dir := filepath.Dir(path)
+	now := time.Now()
+
+	data := Data{
+		Name:    strings.TrimSuffix(file, filepath.Ext(file)),
+		File:    file,
+		Dir:     filepath.ToSlash(dir),
+		Package: PackageName(dir),
+		Author:  Author(),
+		Date:    now.Format(time.DateOnly),
+		Year:    now.Year(),
+	}
+
+	if filepath.Ext(file) == ".go" {
+		goData(&data, root, filepath.Join(root, dir))
+	}
+
+	return data
+}
+
+// PackageName - package name from a directory: its base name lower cased with anything
+// but letters, digits and underscores dropped, main for the workspace root
+func PackageName(dir string) string {
+	base := filepath.Base(dir)
+	if dir == "" || base == "." || base == string(filepath.Separator) {
+		return "main"
+	}
+
+	var name strings.Builder
+	for _, r := range strings.ToLower(base) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			name.WriteRune(r)
+		}
+	}
+
+	pkg := name.String()
+	if pkg == "" || unicode.IsDigit(rune(pkg[0])) {
+		return "main"
+	}
+	return pkg
+}
+
+// Author - git user.name, falling back to the login name
+func Author() string {
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+
+	if current, err := user.Current(); err == nil {
+		if current.Name != "" {
+			return current.Name
+		}
+		return current.Username
+	}
+
+	return ""
+}
+
+func (s *Set) add(fsys fs.FS, source, dir string) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("error reading templates %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileSuffix) {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), fileSuffix)
+		ext := filepath.Ext(base)
+		name := strings.TrimSuffix(base, ext)
+		if name == "" || ext == "" {
+			continue
+		}
+
+		s.templates[key(name, ext)] = &Template{
+			Name:   name,
+			Ext:    ext,
+			Source: source,
+			Path:   filepath.Join(dir, entry.Name()),
+			fsys:   fsys,
+		}
+	}
+
+	return nil
+}
+
+func key(name, ext string) string {
+	return name + ext
+}
+
+// funcs - helpers available inside templates
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"title": pascalCase,
+	"camel": camelCase,
+	"base":  path.Base,
+
+	// pipeline friendly argument order: {{.Name | trimSuffix "_test"}}
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+}
+
+// pascalCase - user_store and user-store become UserStore
+func pascalCase(s string) string {
+	var out strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		out.WriteRune(unicode.ToUpper(runes[0]))
+		out.WriteString(string(runes[1:]))
+	}
+	return out.String()
+}
+
+// camelCase - user_store and user-store become userStore
+func camelCase(s string) string {
+	pascal := []rune(pascalCase(s))
+	if len(pascal) == 0 {
+		return ""
+	}
+	pascal[0] = unicode.ToLower(pascal[0])
+	return string(pascal)
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Schema - the subset of JSON Schema used to describe tool arguments
+type Schema struct {
+	Type                 string             `json:"type"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// Object - object schema, unknown properties are rejected
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	closed := false
+	return &Schema{
+		Type:                 "object",
+		Properties:           properties,
+		Required:             required,
+		AdditionalProperties: &closed,
+	}
+}
+
+// String - string schema
+func String(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+// Enum - string schema restricted to the given values
+func Enum(description string, values ...string) *Schema {
+	return &Schema{Type: "string", Description: description, Enum: values}
+}
+
+// Boolean - boolean schema
+func Boolean(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+// Integer - integer schema
+func Integer(description string) *Schema {
+	return &Schema{Type: "integer", Description: description}
+}
+
+// Array - array schema of items
+func Array(description string, items *Schema) *Schema {
+	return &Schema{Type: "array", Description: description, Items: items}
+}
+
+// Validate - check a JSON document against the schema
+func (s *Schema) Validate(data json.RawMessage) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("arguments are not valid JSON: %w", err)
+	}
+
+	return s.validate("arguments", value)
+}
+
+func (s *Schema) validate(path string, value any) error {
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s must be an object", path)
+		}
+
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s.%s is required", path, name)
+			}
+		}
+
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s.%s is not a known property", path, name)
+				}
+				continue
+			}
+			if err := property.validate(path+"."+name, object[name]); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be an array", path)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", path)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			return fmt.Errorf("%s must be one of %s", path, strings.Join(s.Enum, ", "))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", path)
+		}
+
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be a %s", path, s.Type)
+		}
+		if s.Type == "integer" {
+			f, err := number.Float64()
+			if err != nil || f != math.Trunc(f) {
+				return fmt.Errorf("%s must be an integer", path)
+			}
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrUnknownTool - no tool is registered under the name
+	ErrUnknownTool = errors.New("unknown tool")
+
+	// ErrInvalidArguments - arguments do not match the tool schema
+	ErrInvalidArguments = errors.New("invalid tool arguments")
+)
+
+// Tool - an operation that can be enumerated and called by name, by commands and the model alike
+type Tool interface {
+	Name() string
+	Description() string
+	Schema() *Schema
+	Mutating() bool
+	Execute(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// Spec - static description of a tool
+type Spec struct {
+	Name        string
+	Description string
+	Schema      *Schema
+	Mutating    bool
+}
+
+// ExecuteFunc - tool implementation, args have already been validated against the schema
+type ExecuteFunc func(ctx context.Context, args json.RawMessage) (string, error)
+
+// New - tool from a spec and its implementation
+func New(spec Spec, fn ExecuteFunc) Tool {
+	if spec.Schema == nil {
+		spec.Schema = Object(nil)
+	}
+	return &funcTool{spec: spec, fn: fn}
+}
+
+type funcTool struct {
+	spec Spec
+	fn   ExecuteFunc
+}
+
+func (t *funcTool) Name() string        { return t.spec.Name }
+func (t *funcTool) Description() string { return t.spec.Description }
+func (t *funcTool) Schema() *Schema     { return t.spec.Schema }
+func (t *funcTool) Mutating() bool      { return t.spec.Mutating }
+
+func (t *funcTool) Execute(ctx context.Context, args json.RawMessage) (string, error) {
+	return t.fn(ctx, args)
+}
+
+// Definition - serialisable form of a tool, as dumped by `tools list --json`
+type Definition struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Mutating    bool    `json:"mutating"`
+	Parameters  *Schema `json:"parameters"`
+}
+
+// Registry - set of tools addressed by name
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}
+
+// NewRegistry - create an empty registry
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+// Register - add tools, names must be unique
+func (r *Registry) Register(tools ...Tool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tool := range tools {
+		if tool.Name() == "" {
+			return fmt.Errorf("tool name cannot be empty")
+		}
+		if _, ok := r.tools[tool.Name()]; ok {
+			return fmt.Errorf("tool %q is already registered", tool.Name())
+		}
+		r.tools[tool.Name()] = tool
+	}
+
+	return nil
+}
+
+// Get - tool by name
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// List - every tool sorted by name
+func (r *Registry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		list = append(list, tool)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+
+	return list
+}
+
+// Definitions - serialisable definitions of every tool sorted by name
+func (r *Registry) Definitions() []Definition {
+	var definitions []Definition
+	for _, tool := range r.List() {
+		definitions = append(definitions, Definition{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Mutating:    tool.Mutating(),
+			Parameters:  tool.Schema(),
+		})
+	}
+	return definitions
+}
+
+// Execute - validate the arguments against the tool schema and run it
+func (r *Registry) Execute(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	tool, ok := r.Get(name)
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownTool, name)
+	}
+
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+
+	if err := tool.Schema().Validate(args); err != nil {
+		return "", fmt.Errorf("%w for %s: %v", ErrInvalidArguments, name, err)
+	}
+
+	return tool.Execute(ctx, args)
+}
+
+// Call - Execute with arguments marshalled from a Go value
+func (r *Registry) Call(ctx context.Context, name string, args any) (string, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("%w for %s: %v", ErrInvalidArguments, name, err)
+	}
+
+	return r.Execute(ctx, name, data)
+}
@@ -0,0 +1,493 @@
+package trash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/nathanmbicho/agent-code-assignment/pkg/workspace"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	filesDir     = "files"
+	infoDir      = "info"
+	infoExt      = ".trashinfo"
+	infoHeader   = "[Trash Info]"
+	dateLayout   = "2006-01-02T15:04:05"
+	maxNameTries = 10000
+)
+
+// LocationEnv - environment variable selecting the trash deletes go to
+const LocationEnv = "AGENT_CODE_TRASH"
+
+// WorkspaceDir - per workspace trash, relative to the workspace root
+var WorkspaceDir = filepath.Join(".agent-code", "trash")
+
+var (
+	// ErrNotFound - no trashed item matches
+	ErrNotFound = errors.New("item not found in trash")
+
+	// ErrRestoreConflict - something already exists at the original path
+	ErrRestoreConflict = errors.New("original path already exists")
+
+	// ErrOutsideRoot - the item was deleted from outside the root the trash is opened for
+	ErrOutsideRoot = errors.New("was not deleted from this workspace")
+)
+
+// Trash - a trash directory laid out as in the freedesktop.org Trash specification,
+// trashed items live in files/ and their metadata in info/<name>.trashinfo
+type Trash struct {
+	Dir string
+
+	// GitIgnore - keep the trash out of version control, set for project local trashes
+	GitIgnore bool
+
+	// Root - workspace the trash is used for, when set only items deleted from below it are
+	// listed, restored and removed. the home trash holds the items of every project
+	Root string
+}
+
+// Item - a trashed file or directory
+type Item struct {
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	IsDir        bool      `json:"is_dir"`
+}
+
+// Home - the user's trash, $XDG_DATA_HOME/Trash defaulting to ~/.local/share/Trash
+func Home() (*Trash, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("error getting home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+
+	return &Trash{Dir: filepath.Join(dir, "Trash")}, nil
+}
+
+// ForWorkspace - the project local trash under .agent-code/trash
+func ForWorkspace(root string) *Trash {
+	return &Trash{Dir: filepath.Join(root, WorkspaceDir), GitIgnore: true, Root: root}
+}
+
+// Open - trash for a location: "workspace" (the default) or "home", only the items deleted
+// from below root are in reach
+func Open(root, location string) (*Trash, error) {
+	switch location {
+	case "", "workspace":
+		return ForWorkspace(root), nil
+	case "home":
+		bin, err := Home()
+		if err != nil {
+			return nil, err
+		}
+		bin.Root = root
+		return bin, nil
+	default:
+		return nil, fmt.Errorf("unknown trash location %q, expected workspace or home", location)
+	}
+}
+
+// Contains - check whether a path is the trash or inside it
+func (t *Trash) Contains(path string) bool {
+	return within(t.Dir, path)
+}
+
+// Put - move a file or directory into the trash, path must be absolute
+func (t *Trash) Put(path string) (*Item, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("trash path must be absolute: %s", path)
+	}
+	if t.Contains(path) {
+		return nil, fmt.Errorf("%s is inside the trash, delete it permanently instead", path)
+	}
+	if within(path, t.Dir) {
+		return nil, fmt.Errorf("%s contains the trash, delete it permanently instead", path)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing path: %w", err)
+	}
+
+	if err := t.init(); err != nil {
+		return nil, err
+	}
+
+	item := &Item{OriginalPath: path, DeletedAt: time.Now().Truncate(time.Second), IsDir: info.IsDir()}
+
+	// reserve a unique name by creating its info file first
+	infoFile, name, err := t.reserve(filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	item.Name = name
+
+	_, err = fmt.Fprintf(infoFile, "%s\nPath=%s\nDeletionDate=%s\n", infoHeader, escapePath(path), item.DeletedAt.Format(dateLayout))
+	if closeErr := infoFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(t.infoPath(name))
+		return nil, fmt.Errorf("error writing trash info: %w", err)
+	}
+
+	if err := move(path, t.filePath(name)); err != nil {
+		_ = os.Remove(t.infoPath(name))
+		return nil, fmt.Errorf("error moving %s to trash: %w", path, err)
+	}
+
+	return item, nil
+}
+
+// List - trashed items, most recently deleted first
+func (t *Trash) List() ([]Item, error) {
+	entries, err := os.ReadDir(filepath.Join(t.Dir, infoDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading trash: %w", err)
+	}
+
+	var items []Item
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), infoExt) {
+			continue
+		}
+
+		item, err := t.readInfo(strings.TrimSuffix(entry.Name(), infoExt))
+		if err != nil || t.checkRoot(item) != nil {
+			// skip orphaned or malformed entries, and those of other workspaces, rather than failing the whole listing
+			continue
+		}
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].DeletedAt.Equal(items[j].DeletedAt) {
+			return items[i].DeletedAt.After(items[j].DeletedAt)
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	return items, nil
+}
+
+// Find - item by its trash name, or the most recently deleted item with that original path
+func (t *Trash) Find(nameOrPath string) (*Item, error) {
+	items, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.Name == nameOrPath {
+			return &item, nil
+		}
+	}
+
+	if abs, err := filepath.Abs(nameOrPath); err == nil {
+		for _, item := range items {
+			if item.OriginalPath == abs {
+				return &item, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, nameOrPath)
+}
+
+// Restore - move an item back to its original path, parent directories are recreated
+func (t *Trash) Restore(name string) (*Item, error) {
+	item, err := t.readInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkRoot(item); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Lstat(item.OriginalPath); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRestoreConflict, item.OriginalPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory: %w", err)
+	}
+
+	if err := move(t.filePath(name), item.OriginalPath); err != nil {
+		return nil, fmt.Errorf("error restoring %s: %w", item.OriginalPath, err)
+	}
+
+	if err := os.Remove(t.infoPath(name)); err != nil {
+		return nil, fmt.Errorf("error removing trash info: %w", err)
+	}
+
+	return item, nil
+}
+
+// Remove - permanently delete a single item
+func (t *Trash) Remove(name string) error {
+	item, err := t.readInfo(name)
+	if err != nil {
+		return err
+	}
+	if err := t.checkRoot(item); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(t.filePath(name)); err != nil {
+		return fmt.Errorf("error deleting %s: %w", name, err)
+	}
+
+	return os.Remove(t.infoPath(name))
+}
+
+// Empty - permanently delete everything in the trash, returns the number of items removed
+func (t *Trash) Empty() (int, error) {
+	items, err := t.List()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, item := range items {
+		if err := t.Remove(item.Name); err != nil {
+			return i, err
+		}
+	}
+
+	// leftovers without a matching info file, a shared trash keeps those of other workspaces
+	if t.Root != "" && !within(t.Root, t.Dir) {
+		return len(items), nil
+	}
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.RemoveAll(filepath.Join(t.Dir, dir)); err != nil {
+			return len(items), fmt.Errorf("error emptying trash: %w", err)
+		}
+	}
+
+	return len(items), nil
+}
+
+func (t *Trash) init() error {
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(filepath.Join(t.Dir, dir), 0700); err != nil {
+			return fmt.Errorf("error creating trash directory: %w", err)
+		}
+	}
+
+	if t.GitIgnore {
+		ignore := filepath.Join(t.Dir, ".gitignore")
+		if _, err := os.Stat(ignore); os.IsNotExist(err) {
+			if err := os.WriteFile(ignore, []byte("*\n"), 0600); err != nil {
+				return fmt.Errorf("error creating trash directory: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRoot - the item was deleted from below Root and goes back there, its parent directories
+// are resolved so a symlink cannot send it elsewhere
+func (t *Trash) checkRoot(item *Item) error {
+	if t.Root == "" {
+		return nil
+	}
+
+	ws := &workspace.Workspace{Root: t.Root}
+	resolved, err := ws.ResolveEntry(item.OriginalPath)
+	if err != nil || resolved != filepath.Clean(item.OriginalPath) || resolved == t.Root || t.Contains(resolved) {
+		return fmt.Errorf("%s %w", item.Name, ErrOutsideRoot)
+	}
+	return nil
+}
+
+func (t *Trash) filePath(name string) string {
+	return filepath.Join(t.Dir, filesDir, name)
+}
+
+func (t *Trash) infoPath(name string) string {
+	return filepath.Join(t.Dir, infoDir, name+infoExt)
+}
+
+// reserve - create the info file for the first free name: base, base.2, base.3 ...
+func (t *Trash) reserve(base string) (*os.File, string, error) {
+	for i := 1; i <= maxNameTries; i++ {
+		name := base
+		if i > 1 {
+			name = base + "." + strconv.Itoa(i)
+		}
+
+		// a leftover file without info would be overwritten, skip those names too
+		if _, err := os.Lstat(t.filePath(name)); err == nil {
+			continue
+		}
+
+		file, err := os.OpenFile(t.infoPath(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("error writing trash info: %w", err)
+		}
+
+		return file, name, nil
+	}
+
+	return nil, "", fmt.Errorf("no free trash name for %s", base)
+}
+
+func (t *Trash) readInfo(name string) (*Item, error) {
+	file, err := os.Open(t.infoPath(name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading trash info: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	item := &Item{Name: name}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "Path":
+			path, err := url.PathUnescape(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path in trash info %s: %w", name, err)
+			}
+			item.OriginalPath = path
+		case "DeletionDate":
+			deletedAt, err := time.ParseInLocation(dateLayout, value, time.Local)
+			if err == nil {
+				item.DeletedAt = deletedAt
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trash info: %w", err)
+	}
+	if item.OriginalPath == "" {
+		return nil, fmt.Errorf("trash info %s has no path", name)
+	}
+
+	info, err := os.Lstat(t.filePath(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s has no trashed file", ErrNotFound, name)
+	}
+	item.IsDir = info.IsDir()
+
+	return item, nil
+}
+
+// within - check whether path is dir or below it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// escapePath - percent-encode a path as the spec requires, keeping the separators
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// move - rename, falling back to copy and delete across devices
+func move(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	var linkErr *os.LinkError
+	if !errors.As(err, &linkErr) || !errors.Is(linkErr.Err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+
+	return os.RemoveAll(src)
+}
+
+func copyTree(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = in.Close()
+		}()
+
+		out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	}
+}
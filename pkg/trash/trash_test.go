@@ -0,0 +1,84 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHomeTrashConfinedToRoot(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, other := filepath.Join(base, "project"), filepath.Join(base, "other")
+	for _, dir := range []string{project, other} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	shared := &Trash{Dir: filepath.Join(base, "Trash")}
+	mine, err := shared.Put(filepath.Join(project, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := shared.Put(filepath.Join(other, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bin := &Trash{Dir: shared.Dir, Root: project}
+	items, err := bin.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Name != mine.Name {
+		t.Fatalf("List() = %v, want only %s", items, mine.Name)
+	}
+
+	if _, err := bin.Restore(theirs.Name); !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("Restore(%s) error = %v, want %v", theirs.Name, err, ErrOutsideRoot)
+	}
+	if err := bin.Remove(theirs.Name); !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("Remove(%s) error = %v, want %v", theirs.Name, err, ErrOutsideRoot)
+	}
+
+	if count, err := bin.Empty(); err != nil || count != 1 {
+		t.Fatalf("Empty() = %d, %v, want 1 item", count, err)
+	}
+	if _, err := shared.Restore(theirs.Name); err != nil {
+		t.Fatalf("item of the other workspace was lost: %v", err)
+	}
+}
+
+func TestRestoreRefusesPathsOutsideRoot(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := ForWorkspace(root)
+	if err := bin.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// an info file written by hand pointing outside the workspace
+	target := filepath.Join(t.TempDir(), "planted")
+	if err := os.WriteFile(bin.infoPath("evil"), []byte(infoHeader+"\nPath="+escapePath(target)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bin.filePath("evil"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bin.Restore("evil"); !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("Restore() error = %v, want %v", err, ErrOutsideRoot)
+	}
+	if _, err := os.Lstat(target); !os.IsNotExist(err) {
+		t.Fatalf("file was restored outside the workspace: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"sort"
+)
+
+// DefaultTheme - theme the styles are built with
+const DefaultTheme = "default"
+
+// Theme - colors the styles are built from, empty means no color
+type Theme struct {
+	Error   string
+	Success string
+	Header  string
+	Code    string
+	Border  string
+	Text    string
+	Info    string
+	Input   string
+	Surface string
+
+	// Syntax - chroma style used to highlight code, empty disables highlighting
+	Syntax string
+}
+
+// SyntaxStyle - chroma style of the applied theme
+var SyntaxStyle = Themes[DefaultTheme].Syntax
+
+// Themes - built in themes, selected with ui.theme in the config
+var Themes = map[string]Theme{
+	DefaultTheme: {
+		Error:   ErrorColor,
+		Success: SuccessColor,
+		Header:  HeaderColor,
+		Code:    CodeColor,
+		Border:  BorderColor,
+		Text:    TextColor,
+		Info:    InfoColor,
+		Input:   "62",
+		Surface: "235",
+		Syntax:  "monokai",
+	},
+	// darker colors that stay readable on a light terminal background
+	"light": {
+		Error:   "160",
+		Success: "28",
+		Header:  "25",
+		Code:    "91",
+		Border:  "31",
+		Text:    "237",
+		Info:    "130",
+		Input:   "61",
+		Surface: "254",
+		Syntax:  "github",
+	},
+	// no colors at all, only bold and italic
+	"mono": {},
+}
+
+// ThemeNames - names of the built in themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyTheme - rebuild the shared styles with the colors of a theme
+func ApplyTheme(name string) error {
+	if name == "" {
+		name = DefaultTheme
+	}
+
+	theme, ok := Themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q. available: %v", name, ThemeNames())
+	}
+
+	TextStyle = TextStyle.Foreground(color(theme.Text))
+	ErrorStyle = ErrorStyle.Foreground(color(theme.Error))
+	SuccessStyle = SuccessStyle.Foreground(color(theme.Success))
+	SuccessStyle2 = SuccessStyle2.Foreground(color(theme.Success))
+	HeaderStyle = HeaderStyle.Foreground(color(theme.Header))
+	InfoStyle = InfoStyle.Foreground(color(theme.Info))
+	CodeStyle = CodeStyle.Foreground(color(theme.Code)).Background(color(theme.Surface))
+	CLIStyle = CLIStyle.BorderForeground(color(theme.Border))
+	InputStyle = InputStyle.BorderForeground(color(theme.Input)).Foreground(color(theme.Text))
+	DiffAddStyle = DiffAddStyle.Foreground(color(theme.Success))
+	DiffRemoveStyle = DiffRemoveStyle.Foreground(color(theme.Error))
+	DiffHunkStyle = DiffHunkStyle.Foreground(color(theme.Border))
+	SyntaxStyle = theme.Syntax
+
+	// language colors are kept unless the theme drops colors altogether
+	if theme == (Theme{}) {
+		GoFileStyle = GoFileStyle.Foreground(lipgloss.NoColor{})
+		JSFileStyle = JSFileStyle.Foreground(lipgloss.NoColor{})
+		PythonFileStyle = PythonFileStyle.Foreground(lipgloss.NoColor{})
+		PHPFileStyle = PHPFileStyle.Foreground(lipgloss.NoColor{})
+	}
+
+	return nil
+}
+
+func color(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
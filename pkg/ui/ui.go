@@ -2,6 +2,7 @@ package ui
 
 import (
 	"github.com/charmbracelet/lipgloss"
+	"strings"
 )
 
 // color constants
@@ -98,6 +99,19 @@ var (
 			Bold(true)
 )
 
+// Diff line styles - added and removed lines and hunk headers of a unified diff
+var (
+	DiffAddStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(SuccessColor))
+
+	DiffRemoveStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ErrorColor))
+
+	DiffHunkStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(BorderColor)).
+			Bold(true)
+)
+
 // Helper functions for common UI elements
 
 // RenderError renders an error message with icon
@@ -125,6 +139,26 @@ func RenderInfo(info string) string {
 	return InfoStyle.Render("💡 " + info)
 }
 
+// RenderDiff colours a unified diff line by line
+func RenderDiff(diff string) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+			lines[i] = TextStyle.Bold(true).Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = DiffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = DiffAddStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = DiffRemoveStyle.Render(line)
+		case strings.HasPrefix(line, "\\"):
+			lines[i] = InfoStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
 // GetFileStyle returns appropriate style based on file extension
 func GetFileStyle(extension string) lipgloss.Style {
 	switch extension {
@@ -0,0 +1,49 @@
+package walker
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitStatus - two letter `git status --porcelain` code of every changed or untracked file
+// below dir by absolute path, e.g. " M" or "??". directories holding changes get " M" too.
+// nil when dir is not in a git repository
+func GitStatus(dir string) map[string]string {
+	toplevel, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	top := strings.TrimSpace(string(toplevel))
+
+	out, err := exec.Command("git", "-C", dir, "status", "--porcelain=v1", "-z", "--untracked-files=all", "--", ".").Output()
+	if err != nil {
+		return nil
+	}
+
+	status := make(map[string]string)
+	records := bytes.Split(out, []byte{0})
+	for i := 0; i < len(records); i++ {
+		record := string(records[i])
+		if len(record) < 4 {
+			continue
+		}
+
+		code, path := record[:2], filepath.Join(top, filepath.FromSlash(record[3:]))
+		// renames and copies are followed by the old path
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+
+		status[path] = code
+		for parent := filepath.Dir(path); len(parent) > len(top); parent = filepath.Dir(parent) {
+			if _, ok := status[parent]; ok {
+				break
+			}
+			status[parent] = " M"
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,216 @@
+package walker
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFiles - files read in every directory, later ones take precedence
+var IgnoreFiles = []string{".gitignore", ".ignore"}
+
+// Pattern - one gitignore line
+type Pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// rule - a pattern and the directory its file was in, relative to the walk root.
+// rules of directories above the walk root have the path from there to the walk root as prefix instead
+type rule struct {
+	base    string
+	prefix  string
+	pattern Pattern
+}
+
+// Ignore - rules of the ignore files found so far, immutable so branches of a walk can share it
+type Ignore struct {
+	rules []rule
+}
+
+// ParsePattern - compile a gitignore line, false for blank lines and comments.
+// a pattern with a slash before its end is anchored to base, any other matches names at any depth
+func ParsePattern(line string) (Pattern, bool) {
+	line = strings.TrimRight(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Pattern{}, false
+	}
+
+	var p Pattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return Pattern{}, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	prefix := "^"
+	if !anchored {
+		prefix = "^(?:.*/)?"
+	}
+
+	re, err := regexp.Compile(prefix + globRegexp(line) + "$")
+	if err != nil {
+		return Pattern{}, false
+	}
+	p.re = re
+
+	return p, true
+}
+
+// Match - the slash separated path relative to the pattern base matches
+func (p Pattern) Match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.re.MatchString(rel)
+}
+
+// Child - rules of dir added to the ones already found, rel is dir relative to the walk root
+func (ig *Ignore) Child(dir, rel string) *Ignore {
+	var added []rule
+	for _, name := range IgnoreFiles {
+		for _, p := range readPatterns(filepath.Join(dir, name)) {
+			added = append(added, rule{base: rel, pattern: p})
+		}
+	}
+
+	if len(added) == 0 {
+		return ig
+	}
+
+	child := &Ignore{rules: make([]rule, 0, len(ig.rules)+len(added))}
+	child.rules = append(append(child.rules, ig.rules...), added...)
+	return child
+}
+
+// Ignored - the last matching rule decides, a negated one re-includes the path
+func (ig *Ignore) Ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range ig.rules {
+		sub, ok := r.relative(rel)
+		if !ok {
+			continue
+		}
+		if r.pattern.Match(sub, isDir) {
+			ignored = !r.pattern.negate
+		}
+	}
+	return ignored
+}
+
+// relative - rel as seen from the directory of the rule, false when rel is not below it
+func (r rule) relative(rel string) (string, bool) {
+	switch {
+	case r.prefix != "":
+		return r.prefix + "/" + rel, true
+	case r.base == "" || r.base == ".":
+		return rel, true
+	case strings.HasPrefix(rel, r.base+"/"):
+		return rel[len(r.base)+1:], true
+	}
+	return "", false
+}
+
+func readPatterns(path string) []Pattern {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var patterns []Pattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, ok := ParsePattern(scanner.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// trimTrailingSpaces - trailing spaces are dropped unless escaped with a backslash
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// globRegexp - gitignore glob as a regular expression: * and ? stop at slashes, ** crosses them
+func globRegexp(glob string) string {
+	var re strings.Builder
+	for i := 0; i < len(glob); {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 3
+		case glob[i:] == "/**":
+			re.WriteString("/.*")
+			i += 3
+		case strings.HasPrefix(glob[i:], "**"):
+			re.WriteString(".*")
+			i += 2
+		case glob[i] == '*':
+			re.WriteString("[^/]*")
+			i++
+		case glob[i] == '?':
+			re.WriteString("[^/]")
+			i++
+		case glob[i] == '[':
+			class, n := charClass(glob[i:])
+			re.WriteString(class)
+			i += n
+		case glob[i] == '\\' && i+1 < len(glob):
+			re.WriteString(regexp.QuoteMeta(glob[i+1 : i+2]))
+			i += 2
+		default:
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			i++
+		}
+	}
+	return re.String()
+}
+
+// charClass - [a-z] or [!0-9] at the start of glob and its length, a lone [ is literal
+func charClass(glob string) (string, int) {
+	i := 1
+	negate := false
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		negate = true
+		i++
+	}
+
+	start := i
+	if i < len(glob) && glob[i] == ']' {
+		i++
+	}
+	for i < len(glob) && glob[i] != ']' {
+		i++
+	}
+	if i >= len(glob) {
+		return regexp.QuoteMeta("["), 1
+	}
+
+	body := strings.ReplaceAll(glob[start:i], `\`, `\\`)
+	body = strings.ReplaceAll(body, "[", `\[`)
+	body = strings.ReplaceAll(body, "]", `\]`)
+	if negate {
+		return "[^/" + body + "]", i + 1
+	}
+	return "[" + body + "]", i + 1
+}
@@ -0,0 +1,206 @@
+package walker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		isDir   bool
+		want    bool
+	}{
+		// without a slash a pattern matches names at any depth
+		{"*.log", "a.log", false, true},
+		{"*.log", "deep/down/a.log", false, true},
+		{"*.log", "a.log.txt", false, false},
+		{"*.log", "logs/a.txt", false, false},
+
+		// a trailing slash only matches directories
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"build/", "src/build", true, true},
+
+		// a leading or inner slash anchors the pattern to its directory
+		{"/build", "build", true, true},
+		{"/build", "src/build", true, false},
+		{"doc/*.txt", "doc/a.txt", false, true},
+		{"doc/*.txt", "doc/sub/a.txt", false, false},
+		{"doc/*.txt", "x/doc/a.txt", false, false},
+
+		// ** crosses directories, * and ? do not
+		{"**/foo", "foo", false, true},
+		{"**/foo", "a/b/foo", true, true},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**/b", "xa/b", false, false},
+		{"abc/**", "abc/x", false, true},
+		{"abc/**", "abc/x/y", false, true},
+		{"abc/**", "abc", true, false},
+		{"a*", "ab/c", false, false},
+		{"?.txt", "a.txt", false, true},
+		{"?.txt", "ab.txt", false, false},
+
+		// character classes
+		{"[a-c].md", "b.md", false, true},
+		{"[a-c].md", "d.md", false, false},
+		{"[!a-c].md", "d.md", false, true},
+		{"[!a-c].md", "a.md", false, false},
+		{"foo[", "foo[", false, true},
+
+		// escapes and whitespace
+		{`\#notes`, "#notes", false, true},
+		{`\!important`, "!important", false, true},
+		{"trailing   ", "trailing", false, true},
+		{`space\ `, "space ", false, true},
+		{"crlf.txt\r", "crlf.txt", false, true},
+		{"a.b", "axb", false, false},
+
+		// negation only changes what a match means, see TestIgnored
+		{"!*.go", "main.go", false, true},
+	}
+
+	for _, tt := range tests {
+		p, ok := ParsePattern(tt.pattern)
+		if !ok {
+			t.Errorf("ParsePattern(%q) = false", tt.pattern)
+			continue
+		}
+		if got := p.Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("ParsePattern(%q).Match(%q, %v) = %v, want %v", tt.pattern, tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestParsePatternSkipped(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "!", "/", "!/"} {
+		if _, ok := ParsePattern(line); ok {
+			t.Errorf("ParsePattern(%q) = true, want it skipped", line)
+		}
+	}
+}
+
+// writeFiles - files below root with their content, directories made as needed
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "*.log\nbuild/\n/top.txt\n",
+		".ignore":        "!keep.log\n",
+		"sub/.gitignore": "!debug.log\n/local.txt\ngen/**\n",
+	})
+
+	ignore := (&Ignore{}).Child(root, ".").Child(filepath.Join(root, "sub"), "sub")
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},
+		{"keep.log", false, false}, // .ignore is read after .gitignore
+		{"sub/a.log", false, true},
+		{"sub/debug.log", false, false}, // re-included below sub only
+		{"debug.log", false, true},
+		{"build", true, true},
+		{"sub/build", true, true},
+		{"top.txt", false, true},
+		{"sub/top.txt", false, false},
+		{"sub/local.txt", false, true}, // anchored to sub
+		{"local.txt", false, false},
+		{"sub/gen/x.go", false, true},
+		{"gen/x.go", false, false},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := ignore.Ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+
+	// a directory without ignore files shares its parent's rules
+	if child := ignore.Child(filepath.Join(root, "none"), "none"); child != ignore {
+		t.Errorf("Child() of a directory without ignore files made new rules")
+	}
+}
+
+func TestAncestorIgnore(t *testing.T) {
+	ws := t.TempDir()
+	writeFiles(t, ws, map[string]string{
+		".gitignore":           "*.log\n/top.txt\napp/cmd/out/\n",
+		"app/.ignore":          "!cmd/keep.log\n",
+		"app/cmd/.gitignore":   "own.txt\n",
+		"app/cmd/main.go":      "",
+		"app/cmd/a.log":        "",
+		"app/cmd/keep.log":     "",
+		"app/cmd/top.txt":      "",
+		"app/cmd/own.txt":      "",
+		"app/cmd/out/x":        "",
+		"app/cmd/sub/deep.log": "",
+	})
+	root := filepath.Join(ws, "app", "cmd")
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},        // unanchored pattern of the workspace root
+		{"sub/deep.log", false, true}, // at any depth
+		{"keep.log", false, false},    // re-included by app/.ignore, relative to app
+		{"top.txt", false, false},     // anchored to the workspace root, not the walk root
+		{"out", true, true},           // a path through the walk root
+		{"out", false, false},         // dir-only
+		{"main.go", false, false},     // nothing matches
+		{"own.txt", false, false},     // the walk root's own files are read by Child, not here
+	}
+
+	ignore := ancestorIgnore(ws, root)
+	for _, tt := range tests {
+		if got := ignore.Ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+
+	for name, dir := range map[string]string{"walk root is the ignore root": ws, "walk root outside": t.TempDir(), "no ignore root": ""} {
+		ignoreRoot := ws
+		if dir == "" {
+			ignoreRoot, dir = "", root
+		}
+		if rules := ancestorIgnore(ignoreRoot, dir).rules; len(rules) != 0 {
+			t.Errorf("%s: ancestorIgnore() = %d rules, want none", name, len(rules))
+		}
+	}
+
+	// a walk below the workspace leaves out what the ancestors and the walk root ignore
+	tree, err := Walk(context.Background(), root, Options{Ignore: true, IgnoreRoot: ws, Hidden: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels []string
+	for _, node := range tree.Flatten() {
+		rels = append(rels, node.Rel)
+	}
+	want := []string{".", "sub", ".gitignore", "keep.log", "main.go", "top.txt"}
+	if !reflect.DeepEqual(rels, want) {
+		t.Errorf("Walk() = %q, want %q", rels, want)
+	}
+}
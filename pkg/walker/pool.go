@@ -0,0 +1,101 @@
+package walker
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// defaultWorkers - reading directories mostly waits on the disk, so more workers than CPUs
+func defaultWorkers() int {
+	return min(4*runtime.NumCPU(), 64)
+}
+
+// pool - workers taking directories off a shared queue, reading one can queue more
+type pool struct {
+	ctx      context.Context
+	workers  int
+	progress func(done, found int)
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []dirJob
+	pending int // queued or being read
+	done    int
+	found   int
+}
+
+func newPool(ctx context.Context, workers int, progress func(done, found int)) *pool {
+	p := &pool{ctx: ctx, workers: workers, progress: progress}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// run - read from the first job until no directory is left, the context error when cancelled first
+func (p *pool) run(read func(dirJob) []dirJob, first dirJob) error {
+	p.queue = append(p.queue, first)
+	p.pending, p.found = 1, 1
+
+	// wake the waiting workers so they notice the cancellation
+	stop := context.AfterFunc(p.ctx, func() {
+		p.mu.Lock()
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, ok := p.next()
+				if !ok {
+					return
+				}
+				p.complete(read(job))
+			}
+		}()
+	}
+	wg.Wait()
+
+	return p.ctx.Err()
+}
+
+// next - the next directory to read, false once all are read or the walk is cancelled
+func (p *pool) next() (dirJob, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.queue) == 0 && p.pending > 0 && p.ctx.Err() == nil {
+		p.cond.Wait()
+	}
+	if p.pending == 0 || p.ctx.Err() != nil {
+		return dirJob{}, false
+	}
+
+	// last in first out goes depth first, which keeps the queue short on wide trees
+	job := p.queue[len(p.queue)-1]
+	p.queue = p.queue[:len(p.queue)-1]
+	return job, true
+}
+
+// complete - a directory is read, queue its subdirectories
+func (p *pool) complete(next []dirJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queue = append(p.queue, next...)
+	p.pending += len(next) - 1
+	p.found += len(next)
+	p.done++
+
+	if p.progress != nil {
+		p.progress(p.done, p.found)
+	}
+
+	if len(next) > 0 || p.pending == 0 {
+		p.cond.Broadcast()
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package walker
+
+import "io/fs"
+
+// owner - files have no owner uid outside unix
+func owner(fs.FileInfo) string {
+	return ""
+}
+
+// identity - no inodes to compare, cycles through symlinks are caught by the path check instead
+func identity(fs.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package walker
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// owners - user names by uid, looked up once
+var owners sync.Map
+
+// owner - name of the user owning the file, the uid when it has no name
+func owner(info fs.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	if name, ok := owners.Load(uid); ok {
+		return name.(string)
+	}
+
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	owners.Store(uid, name)
+	return name
+}
+
+// identity - device and inode of a file, the same for every path reaching it
+func identity(info fs.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,431 @@
+package walker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPattern - an include or exclude glob is blank, a comment or negated
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// Options - what the walk includes, the zero value lists every non hidden entry
+type Options struct {
+	// Depth - levels below the root to list, 0 for no limit
+	Depth int
+
+	// Include - glob patterns a file must match, directories are kept when something below them matches
+	Include []string
+
+	// Exclude - glob patterns of files and directories to leave out, with their contents
+	Exclude []string
+
+	Hidden   bool
+	DirsOnly bool
+
+	// Ignore - honour .gitignore and .ignore files, .git itself is always left out
+	Ignore bool
+
+	// IgnoreRoot - directory above the walk root whose ignore files also apply, e.g. the workspace root
+	IgnoreRoot string
+
+	// Totals - read directories past the depth limit too, so their TotalSize and Files are complete
+	Totals bool
+
+	// FollowSymlinks - list what symlinks point to, descending into linked directories
+	// unless that would loop or leave Boundary
+	FollowSymlinks bool
+
+	// Boundary - symlinks resolving outside this directory are not followed, e.g. the workspace root
+	Boundary string
+
+	// Workers - directories read at once, 0 for a default based on the number of CPUs
+	Workers int
+
+	// Progress - called after every directory read with the number read and found so far,
+	// never concurrently, so it should return quickly
+	Progress func(done, found int)
+}
+
+var (
+	// ErrOutsideBoundary - a followed symlink points outside Options.Boundary
+	ErrOutsideBoundary = errors.New("symlink points outside the workspace")
+
+	// ErrBrokenLink - a followed symlink points to nothing
+	ErrBrokenLink = errors.New("broken symlink")
+)
+
+// fileID - device and inode number, identifies a directory however it is reached
+type fileID struct {
+	dev, ino uint64
+}
+
+// Node - an entry of the walked tree
+type Node struct {
+	Name     string
+	Path     string // absolute
+	Rel      string // relative to the walk root, slash separated, "." for the root
+	IsDir    bool
+	Children []*Node
+
+	Mode    fs.FileMode // from lstat, so a symlink has fs.ModeSymlink
+	Size    int64
+	ModTime time.Time
+	Owner   string // user name, empty where there are no owners
+	Target  string // where a symlink points
+
+	// TotalSize and Files - size and number of the files below a directory that pass the filters,
+	// past the depth limit only with Options.Totals
+	TotalSize int64
+	Files     int
+
+	// Err - the directory could not be read or the symlink not followed, its children are missing
+	Err error
+
+	// Cycle - a directory that is also one of its own ancestors, through a symlink or bind mount, not descended into
+	Cycle bool
+}
+
+// walk - a walk in progress
+type walk struct {
+	opts    Options
+	include []Pattern
+	exclude []Pattern
+}
+
+// Walk - read the tree below root into memory, children sorted directories first then by name.
+// directories are read by a pool of workers, the tree is the same whatever order they finish in.
+// stops with the context error when ctx is cancelled
+func Walk(ctx context.Context, root string, opts Options) (*Node, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	w := &walk{opts: opts}
+	if opts.Boundary != "" {
+		// followed links are compared by their real path
+		if boundary, err := filepath.EvalSymlinks(opts.Boundary); err == nil {
+			w.opts.Boundary = boundary
+		}
+	}
+	if w.include, err = compileGlobs(opts.Include); err != nil {
+		return nil, err
+	}
+	if w.exclude, err = compileGlobs(opts.Exclude); err != nil {
+		return nil, err
+	}
+
+	ignore := &Ignore{}
+	if opts.Ignore {
+		ignore = ancestorIgnore(opts.IgnoreRoot, root)
+	}
+
+	node := &Node{Name: filepath.Base(root), Path: root, Rel: ".", IsDir: true}
+	node.setInfo(info)
+
+	var ancestors []fileID
+	if id, ok := identity(info); ok {
+		ancestors = append(ancestors, id)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultWorkers()
+	}
+	p := newPool(ctx, workers, opts.Progress)
+	if err := p.run(w.readDir, dirJob{node: node, ignore: ignore, ancestors: ancestors, depth: 1}); err != nil {
+		return nil, err
+	}
+
+	w.finish(node, 1)
+	return node, nil
+}
+
+// Count - number of files and directories below n
+func (n *Node) Count() (files, dirs int) {
+	for _, child := range n.Children {
+		if child.IsDir {
+			dirs++
+			f, d := child.Count()
+			files += f
+			dirs += d
+		} else {
+			files++
+		}
+	}
+	return files, dirs
+}
+
+// dirJob - a directory waiting to be read, ancestors are the identities of the directories from the root down to it
+type dirJob struct {
+	node      *Node
+	ignore    *Ignore
+	ancestors []fileID
+	depth     int
+}
+
+// readDir - list the directory of job, keeping the entries that pass the filters.
+// returns the subdirectories to read next, only the worker of job touches its node
+func (w *walk) readDir(job dirJob) []dirJob {
+	node, ignore := job.node, job.ignore
+
+	entries, err := os.ReadDir(node.Path)
+	if err != nil {
+		node.Err = err
+		return nil
+	}
+
+	if w.opts.Ignore {
+		ignore = ignore.Child(node.Path, node.Rel)
+	}
+
+	var next []dirJob
+	for _, entry := range entries {
+		name := entry.Name()
+		rel := name
+		if node.Rel != "." {
+			rel = node.Rel + "/" + name
+		}
+
+		child := &Node{Name: name, Path: filepath.Join(node.Path, name), Rel: rel, IsDir: entry.IsDir()}
+		info, err := entry.Info()
+		if err != nil {
+			child.Err = err
+		} else {
+			child.setInfo(info)
+		}
+
+		// a followed link to a directory is filtered and listed as a directory
+		if child.Mode&fs.ModeSymlink != 0 {
+			child.Target, _ = os.Readlink(child.Path)
+			if w.opts.FollowSymlinks {
+				info = w.follow(child)
+			}
+		}
+
+		isDir := child.IsDir
+		if !w.keep(name, rel, isDir, ignore) {
+			continue
+		}
+
+		if !isDir {
+			node.TotalSize += child.Size
+			node.Files++
+
+			// still counted in the totals of the directory
+			if !w.opts.DirsOnly {
+				node.Children = append(node.Children, child)
+			}
+			continue
+		}
+
+		if info != nil {
+			if id, ok := identity(info); ok && slices.Contains(job.ancestors, id) {
+				child.Cycle = true
+			}
+		}
+		node.Children = append(node.Children, child)
+
+		if (w.expanded(job.depth) || w.opts.Totals) && !child.Cycle && child.Err == nil {
+			ancestors := job.ancestors[:len(job.ancestors):len(job.ancestors)]
+			if info != nil {
+				if id, ok := identity(info); ok {
+					ancestors = append(ancestors, id)
+				}
+			}
+			next = append(next, dirJob{node: child, ignore: ignore, ancestors: ancestors, depth: job.depth + 1})
+		}
+	}
+
+	return next
+}
+
+// finish - once every directory is read, add up the totals from the bottom, leave out directories
+// the include patterns emptied, drop what is below the depth limit and sort
+func (w *walk) finish(node *Node, depth int) {
+	children := node.Children[:0]
+	for _, child := range node.Children {
+		if !child.IsDir {
+			children = append(children, child)
+			continue
+		}
+
+		expanded := w.expanded(depth)
+		w.finish(child, depth+1)
+		if !expanded {
+			// below the depth limit only the totals are kept
+			child.Children = nil
+		}
+
+		node.TotalSize += child.TotalSize
+		node.Files += child.Files
+
+		// with include patterns a directory only shows when something below it matched
+		if len(w.include) > 0 && child.Files == 0 && child.Err == nil && (expanded || w.opts.Totals) {
+			continue
+		}
+		children = append(children, child)
+	}
+	node.Children = children
+
+	sort.Slice(node.Children, func(i, j int) bool {
+		a, b := node.Children[i], node.Children[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		return a.Name < b.Name
+	})
+}
+
+// expanded - the children of directories at depth are listed
+func (w *walk) expanded(depth int) bool {
+	return w.opts.Depth == 0 || depth < w.opts.Depth
+}
+
+// follow - stat what a symlink points to, the node becomes a directory when that is one.
+// returns the info of the target, nil when the link is broken or leaves the boundary
+func (w *walk) follow(node *Node) fs.FileInfo {
+	target, err := filepath.EvalSymlinks(node.Path)
+	if err != nil {
+		node.Err = fmt.Errorf("%w: %s", ErrBrokenLink, node.Target)
+		return nil
+	}
+
+	if w.opts.Boundary != "" && !within(w.opts.Boundary, target) {
+		node.Err = fmt.Errorf("%w: %s", ErrOutsideBoundary, target)
+		return nil
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		node.Err = err
+		return nil
+	}
+
+	node.IsDir = info.IsDir()
+	node.Size = info.Size()
+	node.ModTime = info.ModTime()
+
+	// a link into its own ancestors loops, even where inodes cannot be compared
+	if node.IsDir {
+		if parent, err := filepath.EvalSymlinks(filepath.Dir(node.Path)); err == nil && within(target, parent) {
+			node.Cycle = true
+		}
+	}
+
+	return info
+}
+
+// within - path is dir or below it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (n *Node) setInfo(info fs.FileInfo) {
+	n.Mode = info.Mode()
+	n.Size = info.Size()
+	n.ModTime = info.ModTime()
+	n.Owner = owner(info)
+}
+
+// Type - file, dir, symlink or other for devices, sockets and pipes
+func (n *Node) Type() string {
+	switch {
+	case n.Mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case n.IsDir:
+		return "dir"
+	case n.Mode.IsRegular():
+		return "file"
+	}
+	return "other"
+}
+
+// Flatten - n and every node below it, depth first in tree order
+func (n *Node) Flatten() []*Node {
+	nodes := []*Node{n}
+	for _, child := range n.Children {
+		nodes = append(nodes, child.Flatten()...)
+	}
+	return nodes
+}
+
+// keep - hidden, ignored, excluded and not included entries are left out
+func (w *walk) keep(name, rel string, isDir bool, ignore *Ignore) bool {
+	if !w.opts.Hidden && strings.HasPrefix(name, ".") {
+		return false
+	}
+	if w.opts.Ignore && (name == ".git" || ignore.Ignored(rel, isDir)) {
+		return false
+	}
+	if matchAny(w.exclude, rel, isDir) {
+		return false
+	}
+
+	if !isDir && len(w.include) > 0 && !matchAny(w.include, rel, isDir) {
+		return false
+	}
+
+	return true
+}
+
+// ancestorIgnore - rules of the ignore files in ignoreRoot and the directories down to the walk root
+func ancestorIgnore(ignoreRoot, root string) *Ignore {
+	ignore := &Ignore{}
+	if ignoreRoot == "" {
+		return ignore
+	}
+
+	rel, err := filepath.Rel(ignoreRoot, root)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ignore
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	dir := ignoreRoot
+	for i := range parts {
+		// patterns of an ancestor match paths relative to it, i.e. below the walk root prefixed with the path to it
+		prefix := strings.Join(parts[i:], "/")
+		for _, name := range IgnoreFiles {
+			for _, p := range readPatterns(filepath.Join(dir, name)) {
+				ignore.rules = append(ignore.rules, rule{prefix: prefix, pattern: p})
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+
+	return ignore
+}
+
+func compileGlobs(globs []string) ([]Pattern, error) {
+	patterns := make([]Pattern, 0, len(globs))
+	for _, glob := range globs {
+		p, ok := ParsePattern(glob)
+		if !ok || p.negate {
+			return nil, fmt.Errorf("%w %q", ErrInvalidPattern, glob)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+func matchAny(patterns []Pattern, rel string, isDir bool) bool {
+	for _, p := range patterns {
+		if p.Match(rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
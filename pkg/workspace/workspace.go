@@ -0,0 +1,218 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RootEnv - environment variable used to pin the workspace root
+const RootEnv = "AGENT_CODE_ROOT"
+
+// maxLinkDepth - symlink hops followed before giving up, same limit as linux
+const maxLinkDepth = 40
+
+var (
+	// ErrOutsideRoot - matched by errors.Is for any path escaping the workspace
+	ErrOutsideRoot = errors.New("path is outside the workspace root")
+
+	// ErrEmptyPath - path value is empty
+	ErrEmptyPath = errors.New("path cannot be empty")
+)
+
+// OutsideRootError - returned when a path resolves outside the workspace root
+type OutsideRootError struct {
+	Path     string
+	Resolved string
+	Root     string
+}
+
+func (e *OutsideRootError) Error() string {
+	if e.Resolved != "" && e.Resolved != e.Path {
+		return fmt.Sprintf("%s resolves to %s which is outside the workspace root %s", e.Path, e.Resolved, e.Root)
+	}
+	return fmt.Sprintf("%s is outside the workspace root %s", e.Path, e.Root)
+}
+
+// Is - lets errors.Is(err, ErrOutsideRoot) match
+func (e *OutsideRootError) Is(target error) bool {
+	return target == ErrOutsideRoot
+}
+
+// Workspace - project root every file command is confined to
+type Workspace struct {
+	Root string
+}
+
+// New - create a workspace from a root directory, resolving symlinks in it
+func New(root string) (*Workspace, error) {
+	if strings.TrimSpace(root) == "" {
+		return nil, ErrEmptyPath
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving workspace root: %w", err)
+	}
+
+	canonical, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving workspace root: %w", err)
+	}
+
+	info, err := os.Stat(canonical)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing workspace root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("workspace root %s is not a directory", canonical)
+	}
+
+	return &Workspace{Root: canonical}, nil
+}
+
+// Detect - resolve the workspace root, first match wins: explicit root, $AGENT_CODE_ROOT,
+// the git toplevel of the current directory, then the current directory itself
+func Detect(root string) (*Workspace, error) {
+	if root != "" {
+		return New(root)
+	}
+
+	if env := os.Getenv(RootEnv); env != "" {
+		return New(env)
+	}
+
+	if top, err := GitToplevel("."); err == nil {
+		return New(top)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting current directory: %w", err)
+	}
+
+	return New(cwd)
+}
+
+// GitToplevel - top level directory of the git repository containing dir
+func GitToplevel(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %w", err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Resolve - canonical absolute path with every symlink resolved, rejected if it leaves the root.
+// the path does not have to exist yet, missing trailing components are kept as typed
+func (w *Workspace) Resolve(path string) (string, error) {
+	return w.resolve(path, true)
+}
+
+// ResolveEntry - like Resolve but the last path component is not followed if it is a symlink,
+// used where the link itself is the target (e.g. deleting a symlink)
+func (w *Workspace) ResolveEntry(path string) (string, error) {
+	return w.resolve(path, false)
+}
+
+// Contains - check whether an absolute canonical path is inside the root
+func (w *Workspace) Contains(path string) bool {
+	rel, err := filepath.Rel(w.Root, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// Rel - path relative to the root, "." for the root itself
+func (w *Workspace) Rel(path string) string {
+	rel, err := filepath.Rel(w.Root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+func (w *Workspace) resolve(path string, followLast bool) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", ErrEmptyPath
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path: %w", err)
+	}
+
+	var resolved string
+	if followLast {
+		resolved, err = canonicalize(abs, 0)
+	} else {
+		var parent string
+		parent, err = canonicalize(filepath.Dir(abs), 0)
+		resolved = filepath.Join(parent, filepath.Base(abs))
+	}
+	if err != nil {
+		return "", fmt.Errorf("error resolving path %s: %w", path, err)
+	}
+
+	if !w.Contains(resolved) {
+		return "", &OutsideRootError{Path: path, Resolved: resolved, Root: w.Root}
+	}
+
+	return resolved, nil
+}
+
+// canonicalize - resolve symlinks for the longest existing prefix of an absolute path,
+// following dangling links by hand so they cannot be used to point outside the root
+func canonicalize(abs string, depth int) (string, error) {
+	if depth > maxLinkDepth {
+		return "", fmt.Errorf("too many levels of symbolic links")
+	}
+
+	// find the deepest component that exists on disk
+	existing := abs
+	var missing []string
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		missing = append([]string{filepath.Base(existing)}, missing...)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		// dangling symlink, follow its target manually
+		target, linkErr := os.Readlink(existing)
+		if linkErr != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(existing), target)
+		}
+
+		resolved, err = canonicalize(target, depth+1)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(append([]string{resolved}, missing...)...), nil
+}
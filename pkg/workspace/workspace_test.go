@@ -0,0 +1,145 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// symlink - link pointing to target, skipping the test where links cannot be made
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(base, "ws")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "a"), outside, filepath.Join(base, "ws-sibling")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{filepath.Join(root, "a", "file.txt"), filepath.Join(outside, "secret.txt")} {
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	symlink(t, filepath.Join(root, "a"), filepath.Join(root, "linkdir"))
+	symlink(t, "a", filepath.Join(root, "relative"))
+	symlink(t, outside, filepath.Join(root, "out"))
+	symlink(t, filepath.Join(outside, "secret.txt"), filepath.Join(root, "filelink"))
+	symlink(t, filepath.Join(root, "missing"), filepath.Join(root, "dangling-in"))
+	symlink(t, filepath.Join(outside, "missing.txt"), filepath.Join(root, "dangling-out"))
+	symlink(t, "../outside/missing", filepath.Join(root, "dangling-relative"))
+	symlink(t, "dangling-out", filepath.Join(root, "dangling-chain"))
+	symlink(t, "loop", filepath.Join(root, "loop"))
+
+	ws := &Workspace{Root: root}
+	t.Chdir(root)
+
+	tests := []struct {
+		name  string
+		path  string
+		entry bool   // ResolveEntry instead of Resolve
+		want  string // relative to the root
+		err   error  // nil for any error when want is empty
+	}{
+		{"file", "a/file.txt", false, "a/file.txt", nil},
+		{"root", ".", false, ".", nil},
+		{"absolute inside", filepath.Join(root, "a", "file.txt"), false, "a/file.txt", nil},
+		{"missing components kept", "a/new/deep.txt", false, "a/new/deep.txt", nil},
+		{"dot dot inside", "a/../a/file.txt", false, "a/file.txt", nil},
+		{"empty", " ", false, "", ErrEmptyPath},
+
+		// .. escapes
+		{"dot dot", "..", false, "", ErrOutsideRoot},
+		{"dot dot file", "../outside/secret.txt", false, "", ErrOutsideRoot},
+		{"dot dot through a dir", "a/../../outside", false, "", ErrOutsideRoot},
+		{"dot dot to a missing path", "a/../../nowhere/x.txt", false, "", ErrOutsideRoot},
+		{"sibling sharing the prefix", "../ws-sibling/x", false, "", ErrOutsideRoot},
+
+		// absolute paths outside the root
+		{"absolute outside", filepath.Join(outside, "secret.txt"), false, "", ErrOutsideRoot},
+		{"absolute missing outside", filepath.Join(outside, "new", "x.txt"), false, "", ErrOutsideRoot},
+		{"filesystem root", string(filepath.Separator), false, "", ErrOutsideRoot},
+		{"absolute sibling", filepath.Join(base, "ws-sibling"), true, "", ErrOutsideRoot},
+
+		// symlinked parent directories
+		{"parent link inside", "linkdir/file.txt", false, "a/file.txt", nil},
+		{"relative parent link inside", "relative/new.txt", false, "a/new.txt", nil},
+		{"parent link outside", "out/secret.txt", false, "", ErrOutsideRoot},
+		{"parent link outside, missing file", "out/new/x.txt", false, "", ErrOutsideRoot},
+		{"parent link outside as entry", "out/secret.txt", true, "", ErrOutsideRoot},
+		{"dot dot after a link", "linkdir/../a/file.txt", false, "a/file.txt", nil},
+
+		// the last component is followed by Resolve only
+		{"file link outside", "filelink", false, "", ErrOutsideRoot},
+		{"file link outside as entry", "filelink", true, "filelink", nil},
+		{"dir link outside as entry", "out", true, "out", nil},
+
+		// dangling symlinks are followed by hand
+		{"dangling inside", "dangling-in", false, "missing", nil},
+		{"below dangling inside", "dangling-in/x.txt", false, "missing/x.txt", nil},
+		{"dangling outside", "dangling-out", false, "", ErrOutsideRoot},
+		{"below dangling outside", "dangling-out/x.txt", false, "", ErrOutsideRoot},
+		{"relative dangling outside", "dangling-relative", false, "", ErrOutsideRoot},
+		{"dangling chain outside", "dangling-chain", false, "", ErrOutsideRoot},
+		{"dangling outside as entry", "dangling-out", true, "dangling-out", nil},
+		{"below dangling outside as entry", "dangling-out/x.txt", true, "", ErrOutsideRoot},
+
+		{"link loop", "loop", false, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolve := ws.Resolve
+			if tt.entry {
+				resolve = ws.ResolveEntry
+			}
+
+			got, err := resolve(tt.path)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("resolve(%q) = %q, want an error", tt.path, got)
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("resolve(%q) error = %v, want %v", tt.path, err, tt.err)
+				}
+				if tt.err == nil && errors.Is(err, ErrOutsideRoot) {
+					t.Errorf("resolve(%q) error = %v, want it not to be ErrOutsideRoot", tt.path, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("resolve(%q) error = %v", tt.path, err)
+			}
+			if want := filepath.Join(root, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("resolve(%q) = %q, want %q", tt.path, got, want)
+			}
+		})
+	}
+}
+
+func TestOutsideRootError(t *testing.T) {
+	ws := &Workspace{Root: t.TempDir()}
+
+	path := filepath.Join(ws.Root, "..", "x")
+	_, err := ws.Resolve(path)
+	var outsideErr *OutsideRootError
+	if !errors.As(err, &outsideErr) {
+		t.Fatalf("Resolve(%q) error = %v, want an *OutsideRootError", path, err)
+	}
+	if outsideErr.Path != path || outsideErr.Root != ws.Root {
+		t.Errorf("OutsideRootError = %+v, want the typed path and the root", outsideErr)
+	}
+}